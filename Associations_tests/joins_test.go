@@ -0,0 +1,113 @@
+package test_associations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestJoinsSQL(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create employees table
+	err = db.AutoMigrate(&Employee{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Build the query without executing it.
+	var employees []Employee
+	stmt := db.Session(&gorm.Session{DryRun: true}).Joins("Company").Find(&employees).Statement
+
+	// Test cases
+	assert.Contains(t, stmt.SQL.String(), `LEFT JOIN "companies" AS "company" ON`, "The alias of the joined table should be introduced by AS")
+	assert.Contains(t, stmt.SQL.String(), `"company"."name" AS "company__name"`, "The columns of the association should be selected with their aliases")
+}
+
+func TestJoinsBelongsTo(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create employees table
+	err = db.AutoMigrate(&Employee{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Insert records with their associations
+	err = db.Create(&Employee{Name: "Joel", Company: Company{Name: "Net`Q GmbH"}}).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+	err = db.Create(&Employee{Name: "Maria", Company: Company{Name: "Acme"}}).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+
+	// Query employees with their company
+	var employees []Employee
+	err = db.Joins("Company").Order("employees.id").Find(&employees).Error
+	require.NoError(t, err, "There was an error querying employees with their company")
+
+	// Query an employee by a column of the joined company
+	var employee Employee
+	err = db.InnerJoins("Company").Where("Company.name = ?", "Acme").First(&employee).Error
+	require.NoError(t, err, "There was an error querying an employee by its company")
+
+	// Test cases
+	require.Len(t, employees, 2, "All employees should be returned")
+	assert.Equal(t, "Net`Q GmbH", employees[0].Company.Name, "The company of the first employee should be loaded by the join")
+	assert.Equal(t, "Acme", employees[1].Company.Name, "The company of the second employee should be loaded by the join")
+	assert.Equal(t, "Maria", employee.Name, "The employee should be found by the name of its company")
+	assert.Equal(t, employee.CompanyID, employee.Company.ID, "The company should be loaded by the join")
+}
+
+func TestJoinsHasOne(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create users and credit cards tables
+	err = db.AutoMigrate(&User{}, &CreditCard{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Insert a record with its association
+	err = db.Create(&User{Name: "Joel", CreditCard: CreditCard{Number: "4111"}}).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+
+	// Query the user with its credit card
+	var user User
+	err = db.Joins("CreditCard").First(&user).Error
+	require.NoError(t, err, "There was an error querying the user with its credit card")
+
+	// Test cases
+	assert.Equal(t, "Joel", user.Name, "The user should be returned")
+	assert.Equal(t, "4111", user.CreditCard.Number, "The credit card should be loaded by the join")
+	assert.Equal(t, user.ID, user.CreditCard.UserID, "The credit card should belong to the user")
+}
+
+func TestJoinsExplicit(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create employees table
+	err = db.AutoMigrate(&Employee{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Insert records with their associations
+	err = db.Create(&Employee{Name: "Joel", Company: Company{Name: "Net`Q GmbH"}}).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+	err = db.Create(&Employee{Name: "Maria", Company: Company{Name: "Acme"}}).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+
+	// Query employees with a join defined by SQL
+	var employees []Employee
+	err = db.Joins("INNER JOIN companies AS c ON c.id = employees.company_id").Where("c.name = ?", "Acme").Find(&employees).Error
+	require.NoError(t, err, "There was an error querying employees with an explicit join")
+
+	// Test cases
+	require.Len(t, employees, 1, "Only the employee of the matching company should be returned")
+	assert.Equal(t, "Maria", employees[0].Name, "The employee of the matching company should be returned")
+}
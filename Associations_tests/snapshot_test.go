@@ -0,0 +1,66 @@
+package test_associations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	// Open connection
+	db, err := OpenServerConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create employees table
+	err = db.AutoMigrate(&Employee{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Insert a new record to the data base
+	newEmployee := Employee{
+		Name: "Joel",
+		Company: Company{
+			Name: "Net`Q GmbH",
+		},
+	}
+	err = db.Create(&newEmployee).Error
+	require.NoError(t, err, "There was an error inserting a new record")
+
+	// Pin a session to the current state of the database.
+	snapshot, err := immudbGorm.Snapshot(db)
+	require.NoError(t, err, "There was an error creating a snapshot")
+
+	// Modify the database after the snapshot has been created.
+	err = db.Model(&newEmployee.Company).Update("name", "Acme Inc").Error
+	require.NoError(t, err, "There was an error updating the company")
+	err = db.Create(&Employee{Name: "Dave", CompanyID: newEmployee.CompanyID}).Error
+	require.NoError(t, err, "There was an error inserting a second record")
+
+	// Query the snapshot.
+	var employees []Employee
+	err = snapshot.Preload("Company").Find(&employees).Error
+	require.NoError(t, err, "There was an error querying the snapshot")
+	var count int64
+	err = snapshot.Model(&Employee{}).Count(&count).Error
+	require.NoError(t, err, "There was an error counting in the snapshot")
+
+	// Test cases
+	require.Len(t, employees, 1, "The snapshot should not contain employees created after it")
+	assert.Equal(t, int64(1), count, "The snapshot should not contain employees created after it")
+	assert.Equal(t, "Net`Q GmbH", employees[0].Company.Name, "The preloaded company should be read from the snapshot")
+}
+
+func TestSnapshotEmbedded(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// The current transaction of an embedded database cannot be determined.
+	_, err = immudbGorm.Snapshot(db)
+
+	// Test cases
+	assert.ErrorIs(t, err, immudbGorm.ErrSnapshotUnsupported, "A snapshot of an embedded database should be rejected")
+}
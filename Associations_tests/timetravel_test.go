@@ -0,0 +1,29 @@
+package test_associations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+func TestJoinAsOfTx(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error occurred opening connection")
+
+	// Create employees table
+	err = db.AutoMigrate(&Employee{})
+	require.NoError(t, err, "An error occurred while creating tables")
+
+	// Build the query without executing it.
+	var employees []Employee
+	stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(immudbGorm.AsOfTx(3)).Joins("Company").Find(&employees).Statement
+
+	// Test cases
+	assert.Contains(t, stmt.SQL.String(), `FROM "employees" UNTIL TX 3`, "The main table should be read as of tx 3")
+	assert.Contains(t, stmt.SQL.String(), `JOIN "companies" UNTIL TX 3 AS "company"`, "The joined table should be read as of tx 3")
+}
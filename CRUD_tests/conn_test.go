@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+func TestExistingConnPool(t *testing.T) {
+
+	// Open a connection pool without gorm.
+	url := url.URL{
+		Scheme: "immudbe",
+		Path:   t.TempDir(),
+	}
+	pool, err := sql.Open("immudb", url.String())
+	require.NoError(t, err, "There was an error opening the connection pool")
+	defer pool.Close()
+
+	// Use the connection pool for gorm.
+	db, err := gorm.Open(immudbGorm.New(immudbGorm.Config{Conn: pool}), &gorm.Config{})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Test cases
+	sqlDB, err := db.DB()
+	require.NoError(t, err, "There was an error retrieving the connection pool")
+	assert.Same(t, pool, sqlDB, "gorm should use the provided connection pool")
+
+	// Create a users table and a user with gorm.
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	err = db.Create(&User{Name: "Jose", Age: 33}).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// The user should be visible to raw queries on the shared pool.
+	var name string
+	err = pool.QueryRow("SELECT name FROM users").Scan(&name)
+	require.NoError(t, err, "There was an error querying the shared pool")
+	assert.Equal(t, "Jose", name, "The record created by gorm should be visible on the shared pool")
+}
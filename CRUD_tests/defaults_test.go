@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+// Order has fields with default values.
+type Order struct {
+	ID       uuid.UUID `gorm:"primaryKey;type:UUID;default:gen_random_uuid()"`
+	Status   string    `gorm:"default:'pending'"`
+	Quantity int       `gorm:"default:1"`
+	Code     string    `gorm:"default:random_uuid()"`
+	PlacedAt time.Time `gorm:"default:now()"`
+	Channel  uuid.UUID `gorm:"type:UUID;default:'6ba7b810-9dad-11d1-80b4-00c04fd430c8'"`
+	Note     *string   `gorm:"default:null"`
+}
+
+// Shipment has a default value, which cannot be evaluated.
+type Shipment struct {
+	ID      uint
+	Carrier string `gorm:"default:pick_carrier()"`
+}
+
+func TestDefaultValues(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create an orders table
+	err = db.AutoMigrate(&Order{})
+	require.NoError(t, err, "There was an error creating orders table")
+
+	// Create records with and without values
+	before := time.Now()
+	order := Order{}
+	err = db.Create(&order).Error
+	require.NoError(t, err, "An error occurred while creating a record with default values")
+	note := "gift"
+	placedAt := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	set := Order{Status: "shipped", Quantity: 3, Code: "A1", PlacedAt: placedAt, Channel: uuid.New(), Note: &note}
+	err = db.Create(&set).Error
+	require.NoError(t, err, "An error occurred while creating a record with values")
+	var read, readSet Order
+	err = db.First(&read, "id = ?", order.ID).Error
+	require.NoError(t, err, "An error occurred while reading the record with default values")
+	err = db.First(&readSet, "id = ?", set.ID).Error
+	require.NoError(t, err, "An error occurred while reading the record with values")
+
+	// Test cases
+	assert.NotEqual(t, uuid.Nil, order.ID, "A random uuid should be generated for the id")
+	assert.Equal(t, "pending", read.Status, "The constant default value should be stored")
+	assert.Equal(t, 1, read.Quantity, "The constant default value should be stored")
+	_, err = uuid.Parse(read.Code)
+	assert.NoError(t, err, "A random uuid should be stored in the string field")
+	assert.False(t, read.PlacedAt.Before(before.Truncate(time.Microsecond)), "The current time should be stored")
+	assert.Equal(t, uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), read.Channel, "The default uuid should be stored")
+	assert.Nil(t, read.Note, "The NULL default value should be stored")
+	assert.Equal(t, "shipped", readSet.Status, "Set values should not be replaced by defaults")
+	assert.Equal(t, 3, readSet.Quantity, "Set values should not be replaced by defaults")
+	assert.Equal(t, "A1", readSet.Code, "Set values should not be replaced by defaults")
+	assert.True(t, placedAt.Equal(readSet.PlacedAt), "Set values should not be replaced by defaults")
+	assert.Equal(t, set.Channel, readSet.Channel, "Set values should not be replaced by defaults")
+	require.NotNil(t, readSet.Note, "Set values should not be replaced by defaults")
+	assert.Equal(t, "gift", *readSet.Note, "Set values should not be replaced by defaults")
+}
+
+func TestDefaultValuesBatch(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create an orders table
+	err = db.AutoMigrate(&Order{})
+	require.NoError(t, err, "There was an error creating orders table")
+
+	// Create records at once, only some have values
+	note := "gift"
+	orders := []Order{{Status: "shipped", Note: &note}, {}}
+	err = db.Create(&orders).Error
+	require.NoError(t, err, "An error occurred while creating the records")
+
+	// Test cases
+	assert.NotEqual(t, orders[0].ID, orders[1].ID, "Each record should get its own id")
+	assert.NotEqual(t, orders[0].Code, orders[1].Code, "Each record should get its own uuid")
+	assert.Equal(t, "shipped", orders[0].Status, "Set values should not be replaced by defaults")
+	assert.Equal(t, "pending", orders[1].Status, "The default value should be set")
+	var count int64
+	err = db.Model(&Order{}).Where("note IS NULL").Count(&count).Error
+	require.NoError(t, err, "An error occurred while counting the records")
+	assert.Equal(t, int64(1), count, "The record without a note should be stored with NULL")
+}
+
+func TestUnsupportedDefaultValue(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a shipments table
+	err = db.AutoMigrate(&Shipment{})
+	require.NoError(t, err, "There was an error creating shipments table")
+
+	// Create a record relying on the default value
+	err = db.Create(&Shipment{}).Error
+
+	// Test cases
+	var unsupported *immudbGorm.ErrUnsupportedDefault
+	require.True(t, errors.As(err, &unsupported), "The default value should be rejected with an ErrUnsupportedDefault error")
+	assert.Equal(t, "pick_carrier()", unsupported.Default, "The error should contain the default value")
+	err = db.Create(&Shipment{Carrier: "post"}).Error
+	assert.NoError(t, err, "A record with a value should be created")
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+type Membership struct {
+	UserID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	GroupID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Role    string `gorm:"size:32"`
+}
+
+func TestHistory(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record and update it twice.
+	var newUser = User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	err = db.Model(&newUser).Update("name", "Joel").Error
+	require.NoError(t, err, "An error occurred while updating the record")
+	err = db.Model(&newUser).Update("age", 34).Error
+	require.NoError(t, err, "An error occurred while updating the record")
+
+	// Retrieve all revisions of the user.
+	revisions, err := immudbGorm.History(db, &User{}, newUser.ID)
+	require.NoError(t, err, "An error occurred while retrieving the history of the record")
+
+	// Test cases
+	require.Len(t, revisions, 3, "The record should have three revisions")
+	for i, revision := range revisions {
+		assert.Equal(t, uint64(i+1), revision.Rev, "The revisions should be numbered in ascending order")
+		assert.Equal(t, newUser.ID, revision.Value.(*User).ID, "Every revision should belong to the record")
+	}
+	assert.Equal(t, "Jose", revisions[0].Value.(*User).Name, "The first revision should contain the original name")
+	assert.Equal(t, "Joel", revisions[1].Value.(*User).Name, "The second revision should contain the updated name")
+	assert.Equal(t, 33, revisions[1].Value.(*User).Age, "The second revision should contain the original age")
+	assert.Equal(t, 34, revisions[2].Value.(*User).Age, "The third revision should contain the updated age")
+
+	// Every revision should have been written by its own transaction.
+	for i, revision := range revisions {
+		require.NotZero(t, revision.TxID, "The transaction of the revision should be determined")
+		if i > 0 {
+			assert.Greater(t, revision.TxID, revisions[i-1].TxID, "The revisions should be written in ascending transactions")
+		}
+		var user User
+		err = db.Scopes(immudbGorm.AsOfTx(revision.TxID)).First(&user, newUser.ID).Error
+		require.NoError(t, err, "An error occurred while reading the record after the revision")
+		assert.Equal(t, revision.Value.(*User).Name, user.Name, "The record should contain the revision after its transaction")
+		assert.Equal(t, revision.Value.(*User).Age, user.Age, "The record should contain the revision after its transaction")
+	}
+	var user User
+	err = db.Scopes(immudbGorm.BeforeTx(revisions[1].TxID)).First(&user, newUser.ID).Error
+	require.NoError(t, err, "An error occurred while reading the record before the revision")
+	assert.Equal(t, "Jose", user.Name, "The record should contain the previous revision before the transaction")
+}
+
+func TestHistoryCompositeKey(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a memberships table
+	err = db.AutoMigrate(&Membership{})
+	require.NoError(t, err, "There was an error creating memberships table")
+
+	// Create two memberships sharing the user and update one of them.
+	err = db.Create(&Membership{UserID: 1, GroupID: 1, Role: "member"}).Error
+	require.NoError(t, err, "An error occurred while creating the first membership")
+	err = db.Create(&Membership{UserID: 1, GroupID: 2, Role: "member"}).Error
+	require.NoError(t, err, "An error occurred while creating the second membership")
+	err = db.Model(&Membership{}).Where("user_id = ? AND group_id = ?", 1, 2).Update("role", "admin").Error
+	require.NoError(t, err, "An error occurred while updating the second membership")
+
+	// Retrieve all revisions of the second membership.
+	revisions, err := immudbGorm.History(db, &Membership{}, 1, 2)
+	require.NoError(t, err, "An error occurred while retrieving the history of the record")
+
+	// Test cases
+	require.Len(t, revisions, 2, "The membership should have two revisions")
+	assert.Equal(t, "member", revisions[0].Value.(*Membership).Role, "The first revision should contain the original role")
+	assert.Equal(t, "admin", revisions[1].Value.(*Membership).Role, "The second revision should contain the updated role")
+
+	// Passing an incomplete primary key should fail.
+	_, err = immudbGorm.History(db, &Membership{}, 1)
+	assert.Error(t, err, "An incomplete primary key should be rejected")
+}
+
+func TestHistoryOfTable(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create the users table and an archive with the same columns.
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	err = db.Table("archived_users").AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating archived users table")
+
+	// Create a record in each table and update the archived one.
+	err = db.Create(&User{Name: "Jose", Age: 33}).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	archived := User{Name: "Maria", Age: 40}
+	err = db.Table("archived_users").Create(&archived).Error
+	require.NoError(t, err, "An error occurred while creating an archived record")
+	err = db.Table("archived_users").Where("id = ?", archived.ID).Update("age", 41).Error
+	require.NoError(t, err, "An error occurred while updating the archived record")
+
+	// Retrieve the revisions of the archived user.
+	revisions, err := immudbGorm.History(db.Table("archived_users"), &User{}, archived.ID)
+	require.NoError(t, err, "An error occurred while retrieving the history of the archived record")
+
+	// Test cases
+	require.Len(t, revisions, 2, "The archived record should have two revisions")
+	assert.Equal(t, "Maria", revisions[0].Value.(*User).Name, "The revisions should be read from the archive")
+	assert.Equal(t, 41, revisions[1].Value.(*User).Age, "The second revision should contain the updated age")
+	assert.NotZero(t, revisions[1].TxID, "The transaction of the revision should be determined")
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+// Setting has columns named like reserved words of immudb.
+type Setting struct {
+	ID      uint
+	Table   string
+	Unique  bool
+	Primary int
+}
+
+func TestReservedColumnNames(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a settings table
+	err = db.AutoMigrate(&Setting{})
+	require.NoError(t, err, "There was an error creating settings table")
+
+	// Create, update and query a record
+	setting := Setting{Table: "users", Unique: true, Primary: 1}
+	err = db.Create(&setting).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	err = db.Model(&setting).Updates(map[string]interface{}{"primary": 2}).Error
+	require.NoError(t, err, "An error occurred while updating the record")
+	var settings []Setting
+	err = db.Where(&Setting{Table: "users"}).Find(&settings).Error
+	require.NoError(t, err, "An error occurred while reading the records")
+
+	// Test cases
+	require.Len(t, settings, 1, "The record should be found by a reserved column name")
+	assert.Equal(t, Setting{ID: setting.ID, Table: "users", Unique: true, Primary: 2}, settings[0], "The record should have been updated")
+}
+
+func TestDisableQuoting(t *testing.T) {
+
+	// Open connections with and without quoting
+	quotedDB, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+	url := url.URL{
+		Scheme: "immudbe",
+		Path:   t.TempDir(),
+	}
+	db, err := gorm.Open(immudbGorm.New(immudbGorm.Config{DSN: url.String(), DisableQuoting: true}), &gorm.Config{})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Build queries without executing them.
+	var users []User
+	quoted := quotedDB.Session(&gorm.Session{DryRun: true}).Where(&User{Name: "Jose"}).Find(&users).Statement
+	unquoted := db.Session(&gorm.Session{DryRun: true}).Where(&User{Name: "Jose"}).Find(&users).Statement
+
+	// Test cases
+	assert.Contains(t, quoted.SQL.String(), `FROM "users" WHERE "users"."name" = ?`, "Identifiers should be quoted by default")
+	assert.Contains(t, unquoted.SQL.String(), `FROM users WHERE users.name = ?`, "Identifiers should not be quoted if quoting is disabled")
+}
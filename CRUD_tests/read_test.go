@@ -6,6 +6,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestRead(t *testing.T) {
@@ -77,3 +78,95 @@ func TestLimit(t *testing.T) {
 	// Test cases
 	assert.Len(t, users, 1, "The query should only return one row if the limit is 1")
 }
+
+// createUsers creates users with the given names and ages.
+func createUsers(t *testing.T, db *gorm.DB, names []string, ages []int) {
+	for i, name := range names {
+		err := db.Create(&User{Name: name, Age: ages[i]}).Error
+		require.NoError(t, err, "An error occurred while creating a user")
+	}
+}
+
+func TestOffset(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	createUsers(t, db, []string{"Jose", "Dave", "Maria", "Anna", "Paul"}, []int{33, 35, 40, 28, 51})
+
+	// Query the second page of users
+	var users []User
+	err = db.Order("id").Limit(2).Offset(2).Find(&users).Error
+	require.NoError(t, err, "An error occurred while reading a page of users")
+
+	// Query all users after the first ones
+	var rest []User
+	err = db.Order("id").Offset(3).Find(&rest).Error
+	require.NoError(t, err, "An error occurred while reading the remaining users")
+
+	// Test cases
+	require.Len(t, users, 2, "The page should contain two users")
+	assert.Equal(t, "Maria", users[0].Name, "The page should start after the skipped users")
+	assert.Equal(t, "Anna", users[1].Name, "The page should contain the next user")
+	require.Len(t, rest, 2, "All users after the offset should be returned")
+	assert.Equal(t, "Anna", rest[0].Name, "The remaining users should start after the offset")
+}
+
+func TestHaving(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	createUsers(t, db, []string{"Jose", "Jose", "Maria", "Anna", "Anna"}, []int{33, 35, 40, 28, 51})
+
+	// Query names used more than once
+	// COUNT is a keyword of immudb SQL and cannot be used as alias.
+	type nameCount struct {
+		Name  string
+		Count int64 `gorm:"column:cnt"`
+	}
+	var counts []nameCount
+	err = db.Model(&User{}).Select("name, COUNT(*) AS cnt").Group("name").Having("COUNT(*) > ?", 1).Find(&counts).Error
+	require.NoError(t, err, "An error occurred while grouping users")
+
+	// Test cases
+	assert.ElementsMatch(t, []nameCount{{Name: "Anna", Count: 2}, {Name: "Jose", Count: 2}}, counts, "Only names used more than once should be returned")
+}
+
+func TestDistinct(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	createUsers(t, db, []string{"Jose", "Jose", "Maria"}, []int{33, 33, 40})
+
+	// Query distinct names
+	var names []string
+	err = db.Model(&User{}).Distinct("name").Pluck("name", &names).Error
+	require.NoError(t, err, "An error occurred while reading distinct names")
+
+	// Count distinct names
+	var count int64
+	err = db.Model(&User{}).Distinct("name").Count(&count).Error
+	require.NoError(t, err, "An error occurred while counting distinct names")
+
+	// Test cases
+	assert.ElementsMatch(t, []string{"Jose", "Maria"}, names, "Every name should only be returned once")
+	assert.Equal(t, int64(2), count, "Every name should only be counted once")
+
+	// Distinct queries without columns should keep DISTINCT.
+	stmt := db.Session(&gorm.Session{DryRun: true}).Distinct().Find(&[]User{}).Statement
+	assert.Contains(t, stmt.SQL.String(), "SELECT DISTINCT ", "The query should select distinct rows")
+}
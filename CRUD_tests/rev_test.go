@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+type Account struct {
+	ID      uint
+	Owner   string
+	Balance int
+	Rev     uint64 `gorm:"-:migration" immudb:"rev"`
+}
+
+func TestRev(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create an accounts table
+	err = db.AutoMigrate(&Account{})
+	require.NoError(t, err, "There was an error creating accounts table")
+
+	// Test cases
+	assert.False(t, db.Migrator().HasColumn(&Account{}, "rev"), "No column should be created for the revision")
+
+	// Create a new account record
+	var newAccount = Account{Owner: "Jose", Balance: 100}
+	err = db.Create(&newAccount).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Test cases
+	assert.Equal(t, uint64(1), newAccount.Rev, "A new record should have the revision 1")
+
+	// Read and update the account.
+	var account Account
+	err = db.First(&account, newAccount.ID).Error
+	require.NoError(t, err, "An error occurred while reading the record")
+	assert.Equal(t, uint64(1), account.Rev, "The revision should be read from the database")
+
+	account.Balance = 200
+	err = db.Save(&account).Error
+	require.NoError(t, err, "An error occurred while saving the record")
+	assert.Equal(t, uint64(2), account.Rev, "Saving the record should increment its revision")
+
+	err = db.First(&account, newAccount.ID).Error
+	require.NoError(t, err, "An error occurred while reading the record")
+	assert.Equal(t, uint64(2), account.Rev, "The revision should be read from the database")
+	assert.Equal(t, 200, account.Balance, "The updated balance should be read from the database")
+}
+
+func TestRevConflict(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create an accounts table
+	err = db.AutoMigrate(&Account{})
+	require.NoError(t, err, "There was an error creating accounts table")
+
+	// Create a new account record
+	var newAccount = Account{Owner: "Jose", Balance: 100}
+	err = db.Create(&newAccount).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Read the account twice, simulating two concurrent writers.
+	var first, second Account
+	require.NoError(t, db.First(&first, newAccount.ID).Error, "An error occurred while reading the record")
+	require.NoError(t, db.First(&second, newAccount.ID).Error, "An error occurred while reading the record")
+
+	// The first writer succeeds.
+	err = db.Model(&first).Updates(map[string]interface{}{"balance": 150}).Error
+	require.NoError(t, err, "The first update should succeed")
+
+	// The second writer still uses the old revision.
+	second.Balance = 50
+	err = db.Save(&second).Error
+
+	// Test cases
+	assert.ErrorIs(t, err, immudbGorm.ErrRevisionConflict, "Updating an outdated revision should fail")
+
+	var account Account
+	require.NoError(t, db.First(&account, newAccount.ID).Error, "An error occurred while reading the record")
+	assert.Equal(t, 150, account.Balance, "The outdated update should not have been applied")
+}
+
+func TestRevWithoutMigrationTag(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// A revision field, which is not excluded from migrations explicitly.
+	type Ledger struct {
+		ID      uint
+		Balance int
+		Rev     uint64 `immudb:"rev"`
+	}
+
+	// Create a ledgers table
+	err = db.Migrator().CreateTable(&Ledger{})
+	require.NoError(t, err, "There was an error creating ledgers table")
+
+	// Migrating the table again should not add a column either.
+	err = db.AutoMigrate(&Ledger{})
+	require.NoError(t, err, "There was an error migrating ledgers table")
+	stmt := &gorm.Statement{DB: db}
+	err = stmt.Parse(&Ledger{})
+	require.NoError(t, err, "There was an error parsing the ledger model")
+
+	// Test cases
+	assert.False(t, db.Migrator().HasColumn(&Ledger{}, "rev"), "No column should be created for the revision")
+	assert.False(t, stmt.Schema.LookUpField("rev").IgnoreMigration, "The schema cached by gorm should not be changed")
+
+	// Create a new ledger record
+	ledger := Ledger{Balance: 100}
+	err = db.Create(&ledger).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	assert.Equal(t, uint64(1), ledger.Rev, "A new record should have the revision 1")
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+func TestAsOfTx(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Build the query without executing it.
+	var users []User
+	stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(immudbGorm.AsOfTx(42)).Find(&users).Statement
+
+	// Test cases
+	assert.Contains(t, stmt.SQL.String(), `FROM "users" UNTIL TX 42`, "The query should read the table as of tx 42")
+}
+
+func TestBeforeTx(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Build the query without executing it.
+	var count int64
+	stmt := db.Session(&gorm.Session{DryRun: true}).Model(&User{}).Scopes(immudbGorm.BeforeTx(7)).Count(&count).Statement
+
+	// Test cases
+	assert.Contains(t, stmt.SQL.String(), `FROM "users" BEFORE TX 7`, "The query should read the table before tx 7")
+}
+
+func TestAsOfTime(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Define a new record
+	var newUser = User{Name: "Jose", Age: 33}
+
+	// Create a new user record
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	created := time.Now()
+
+	// Update the user after the point in time which will be queried. immudb
+	// stores the time of transactions in seconds, so the update has to be
+	// committed in the next second to be distinguishable.
+	time.Sleep(time.Until(created.Truncate(time.Second).Add(time.Second)))
+	err = db.Model(&newUser).Update("name", "Joel").Error
+	require.NoError(t, err, "An error occurred while updating the record")
+
+	// Query the user in the state it had right after its creation.
+	var user User
+	err = db.Scopes(immudbGorm.AsOfTime(created)).First(&user, newUser.ID).Error
+	require.NoError(t, err, "An error occurred while reading the past state of the record")
+
+	// Query the number of users and their names at the same point in time.
+	var count int64
+	err = db.Model(&User{}).Scopes(immudbGorm.AsOfTime(created)).Count(&count).Error
+	require.NoError(t, err, "An error occurred while counting the past records")
+	var names []string
+	err = db.Model(&User{}).Scopes(immudbGorm.AsOfTime(created)).Pluck("name", &names).Error
+	require.NoError(t, err, "An error occurred while plucking the past records")
+
+	// Test cases
+	assert.Equal(t, "Jose", user.Name, "The past state of the record should contain the original name")
+	assert.Equal(t, int64(1), count, "There should have been exactly one user")
+	assert.Equal(t, []string{"Jose"}, names, "The past state of the record should contain the original name")
+}
+
+func TestBetween(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Build the query without executing it.
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	var users []User
+	stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(immudbGorm.Between(from, to)).Find(&users).Statement
+
+	// Test cases
+	assert.Contains(t, stmt.SQL.String(), `FROM "users" SINCE ? UNTIL ?`, "The query should read the table between both points in time")
+	require.GreaterOrEqual(t, len(stmt.Vars), 2, "Both points in time should be passed as parameters")
+	assert.Equal(t, from, stmt.Vars[0], "The start of the period should be the first parameter")
+	assert.Equal(t, to, stmt.Vars[1], "The end of the period should be the second parameter")
+}
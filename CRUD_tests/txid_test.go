@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+func TestTxID(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	var newUser = User{Name: "Jose", Age: 33}
+	res := db.Create(&newUser)
+	require.NoError(t, res.Error, "An error occurred while creating a new record")
+	createTx, err := immudbGorm.TxID(res)
+	require.NoError(t, err, "The transaction id of the insert should be available")
+
+	// Update the user record
+	res = db.Model(&newUser).Update("name", "Joel")
+	require.NoError(t, res.Error, "An error occurred while updating the record")
+	updateTx, err := immudbGorm.TxID(res)
+	require.NoError(t, err, "The transaction id of the update should be available")
+
+	// Delete the user record
+	res = db.Delete(&newUser)
+	require.NoError(t, res.Error, "An error occurred while deleting the record")
+	deleteTx, err := immudbGorm.TxID(res)
+	require.NoError(t, err, "The transaction id of the delete should be available")
+
+	// Test cases
+	assert.Greater(t, updateTx, createTx, "The update should be committed after the insert")
+	assert.Greater(t, deleteTx, updateTx, "The delete should be committed after the update")
+
+	// Read the user as it was after its creation.
+	var user User
+	err = db.Scopes(immudbGorm.AsOfTx(createTx)).First(&user, newUser.ID).Error
+	require.NoError(t, err, "An error occurred while reading the past state of the record")
+	assert.Equal(t, "Jose", user.Name, "The past state of the record should contain the original name")
+}
+
+func TestTxIDTransaction(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create two users in a single transaction.
+	var txID uint64
+	ctx := immudbGorm.WithTxIDTarget(context.Background(), &txID)
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&User{Name: "Jose", Age: 33}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&User{Name: "Dave", Age: 35}).Error
+	})
+	require.NoError(t, err, "An error occurred while creating the records")
+
+	// Test cases
+	assert.NotZero(t, txID, "The transaction id of the transaction block should be available")
+
+	var count int64
+	err = db.Model(&User{}).Scopes(immudbGorm.AsOfTx(txID)).Count(&count).Error
+	require.NoError(t, err, "An error occurred while counting the records")
+	assert.Equal(t, int64(2), count, "Both records should be visible after the transaction")
+}
+
+// hiddenTxIDDriver wraps the immudb driver and hides the ids of the immudb
+// transactions reported by it.
+type hiddenTxIDDriver struct {
+	driver.Driver
+}
+
+func (d hiddenTxIDDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return hiddenTxIDConn{Conn: conn}, nil
+}
+
+// hiddenTxIDConn returns results and transactions, which do not report the
+// ids of immudb transactions.
+type hiddenTxIDConn struct {
+	driver.Conn
+}
+
+func (c hiddenTxIDConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return hiddenTxIDStmt{Stmt: stmt}, nil
+}
+
+func (c hiddenTxIDConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c hiddenTxIDConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hiddenTxIDTx{Tx: tx}, nil
+}
+
+func (c hiddenTxIDConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return hiddenTxIDResult{Result: result}, nil
+}
+
+func (c hiddenTxIDConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+type hiddenTxIDStmt struct {
+	driver.Stmt
+}
+
+func (s hiddenTxIDStmt) Exec(args []driver.Value) (driver.Result, error) {
+	result, err := s.Stmt.Exec(args)
+	if err != nil {
+		return nil, err
+	}
+	return hiddenTxIDResult{Result: result}, nil
+}
+
+type hiddenTxIDTx struct {
+	driver.Tx
+}
+
+type hiddenTxIDResult struct {
+	driver.Result
+}
+
+var registerHiddenTxIDDriver sync.Once
+
+func TestTxIDUnavailable(t *testing.T) {
+
+	// Register a driver, which does not report transaction ids.
+	registerHiddenTxIDDriver.Do(func() {
+		pool, err := sql.Open("immudb", "")
+		require.NoError(t, err, "There was an error retrieving the immudb driver")
+		sql.Register("immudb-hidden-tx-id", hiddenTxIDDriver{Driver: pool.Driver()})
+		pool.Close()
+	})
+
+	// Open connection
+	dsn := url.URL{Scheme: "immudbe", Path: t.TempDir()}
+	db, err := gorm.Open(immudbGorm.New(immudbGorm.Config{DriverName: "immudb-hidden-tx-id", DSN: dsn.String()}), &gorm.Config{})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	var newUser = User{Name: "Jose", Age: 33}
+	res := db.Create(&newUser)
+	require.NoError(t, res.Error, "An error occurred while creating a new record")
+
+	// Test cases
+	_, err = immudbGorm.TxID(res)
+	assert.ErrorIs(t, err, immudbGorm.ErrTxIDUnavailable, "A transaction id not reported by the driver should not be guessed")
+
+	var txID uint64
+	ctx := immudbGorm.WithTxIDTarget(context.Background(), &txID)
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&User{Name: "Dave", Age: 35}).Error
+	})
+	require.NoError(t, err, "An error occurred while creating the record")
+	assert.Zero(t, txID, "A transaction id not reported by the driver should not be guessed")
+
+	// The error of a failed write is returned.
+	res = db.Create(&User{Model: gorm.Model{ID: newUser.ID}, Name: "Maria"})
+	require.Error(t, res.Error, "Creating a record with an existing primary key should fail")
+	_, err = immudbGorm.TxID(res)
+	assert.Equal(t, res.Error, err, "The error of the write should be returned")
+}
@@ -1,11 +1,15 @@
 package tests
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
 )
 
 func TestUpdate(t *testing.T) {
@@ -49,3 +53,145 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(t, 100, user.Age, "The update failed")
 
 }
+
+func TestSave(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	newUser := User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Save the complete model, including zero values.
+	newUser.Name = "Joel"
+	newUser.Age = 0
+	err = db.Save(&newUser).Error
+	require.NoError(t, err, "An error occurred while saving the record")
+
+	// Save a model, which does not exist yet.
+	otherUser := User{Name: "Maria", Age: 40}
+	otherUser.ID = newUser.ID + 100
+	err = db.Save(&otherUser).Error
+	require.NoError(t, err, "An error occurred while saving a new record")
+
+	// Test cases
+	var user User
+	err = db.First(&user, newUser.ID).Error
+	require.NoError(t, err, "No user found was with that ID")
+	assert.Equal(t, "Joel", user.Name, "The name should have been saved")
+	assert.Equal(t, 0, user.Age, "The zero value of age should have been saved")
+	err = db.First(&user, otherUser.ID).Error
+	require.NoError(t, err, "The saved new record should exist")
+	assert.Equal(t, "Maria", user.Name, "The new record should have been saved")
+}
+
+func TestUpdatesMap(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	newUser := User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Update with a map, which can set zero values.
+	err = db.Model(&newUser).Updates(map[string]interface{}{"name": "Joel", "age": 0}).Error
+	require.NoError(t, err, "An error occurred while updating")
+
+	// Test cases
+	var user User
+	err = db.First(&user, newUser.ID).Error
+	require.NoError(t, err, "No user found was with that ID")
+	assert.Equal(t, "Joel", user.Name, "The name should have been updated")
+	assert.Equal(t, 0, user.Age, "The age should have been updated to zero")
+	assert.Equal(t, "Joel", newUser.Name, "The model should have been updated as well")
+}
+
+func TestUpdateColumn(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	newUser := User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Update a single column without changing the update time.
+	err = db.Model(&newUser).UpdateColumn("name", "Joel").Error
+	require.NoError(t, err, "An error occurred while updating")
+
+	// Test cases
+	var user User
+	err = db.First(&user, newUser.ID).Error
+	require.NoError(t, err, "No user found was with that ID")
+	assert.Equal(t, "Joel", user.Name, "The name should have been updated")
+	// immudb stores timestamps with a precision of microseconds.
+	assert.True(t, user.UpdatedAt.Equal(newUser.UpdatedAt.Truncate(time.Microsecond)), "The update time should not have been changed")
+}
+
+func TestUpdateExpr(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	newUser := User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Increment the age with an expression.
+	err = db.Model(&newUser).Update("age", gorm.Expr("age + ?", 1)).Error
+	require.NoError(t, err, "An error occurred while updating")
+	err = db.Model(&newUser).UpdateColumn("age", gorm.Expr("age + ?", 2)).Error
+	require.NoError(t, err, "An error occurred while updating")
+
+	// Test cases
+	var user User
+	err = db.First(&user, newUser.ID).Error
+	require.NoError(t, err, "No user found was with that ID")
+	assert.Equal(t, 36, user.Age, "The age should have been incremented")
+}
+
+func TestUpdateUnsupported(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	newUser := User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Test cases
+	var clauseErr *immudbGorm.ErrUnsupportedClause
+	err = db.Model(&User{}).Where("age > ?", 30).Order("age").Limit(1).Update("age", 1).Error
+	assert.True(t, errors.As(err, &clauseErr), "Updates with ORDER BY and LIMIT should be rejected")
+	err = db.Model(&newUser).Updates(map[string]interface{}{"id": newUser.ID + 1}).Error
+	assert.True(t, errors.As(err, &clauseErr), "Changing the primary key should be rejected")
+	err = db.Model(&User{}).Update("age", 1).Error
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause, "Updates without conditions should be rejected")
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestUpsertSQL(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Build the queries without executing them.
+	dryRun := db.Session(&gorm.Session{DryRun: true})
+	user := User{Name: "Jose", Age: 33}
+	updateAll := dryRun.Clauses(clause.OnConflict{UpdateAll: true}).Create(&user).Statement
+	doNothing := dryRun.Clauses(clause.OnConflict{DoNothing: true}).Create(&user).Statement
+	assignAll := dryRun.Select("name", "age").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"created_at", "updated_at", "name", "age"}),
+	}).Create(&user).Statement
+
+	// Test cases
+	assert.NoError(t, updateAll.Error, "Replacing conflicting rows should be supported")
+	assert.Regexp(t, `^UPSERT INTO "users" `, updateAll.SQL.String(), "Replacing conflicting rows should use UPSERT")
+	assert.NotContains(t, updateAll.SQL.String(), "ON CONFLICT", "Replacing conflicting rows should not use ON CONFLICT")
+	assert.NoError(t, doNothing.Error, "Skipping conflicting rows should be supported")
+	assert.Regexp(t, `^INSERT INTO "users" .* ON CONFLICT DO NOTHING$`, doNothing.SQL.String(), "Skipping conflicting rows should use ON CONFLICT DO NOTHING")
+	assert.NoError(t, assignAll.Error, "Updating all inserted columns should be supported")
+	assert.Regexp(t, `^UPSERT INTO "users" `, assignAll.SQL.String(), "Updating all inserted columns should use UPSERT")
+}
+
+func TestUpsertUnsupported(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Test cases
+	conflicts := map[string]clause.OnConflict{
+		"partial update": {DoUpdates: clause.Assignments(map[string]interface{}{"age": 34})},
+		"other columns":  {Columns: []clause.Column{{Name: "name"}}, DoNothing: true},
+		"constraint":     {OnConstraint: "users_name_key", DoNothing: true},
+		"conditional":    {UpdateAll: true, Where: clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "age", Value: 33}}}},
+	}
+	for name, conflict := range conflicts {
+		err := db.Clauses(conflict).Create(&User{Name: "Jose", Age: 33}).Error
+		var clauseErr *immudbGorm.ErrUnsupportedClause
+		assert.True(t, errors.As(err, &clauseErr), "The conflict handling %s should be rejected", name)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a user and insert it again with new values.
+	user := User{Name: "Jose", Age: 33}
+	err = db.Create(&user).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	user.Age = 34
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&user).Error
+	require.NoError(t, err, "An error occurred while upserting the record")
+
+	// Inserting it again with conflicts being skipped.
+	skipped := user
+	skipped.Age = 35
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&skipped).Error
+	require.NoError(t, err, "An error occurred while inserting the record again")
+
+	// Test cases
+	var users []User
+	err = db.Find(&users).Error
+	require.NoError(t, err, "An error occurred while reading the records")
+	require.Len(t, users, 1, "There should only be one record")
+	assert.Equal(t, 34, users[0].Age, "The record should have been replaced by the upsert, but not by the skipped insert")
+
+	// Upsert a model with a revision field.
+	err = db.AutoMigrate(&Account{})
+	require.NoError(t, err, "There was an error creating accounts table")
+	account := Account{Owner: "Jose", Balance: 100}
+	err = db.Create(&account).Error
+	require.NoError(t, err, "An error occurred while creating a new account")
+	account.Balance = 200
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&account).Error
+	require.NoError(t, err, "An error occurred while upserting the account")
+	upsertedRev := account.Rev
+	skippedAccount := account
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&skippedAccount).Error
+	require.NoError(t, err, "An error occurred while inserting the account again")
+
+	// Test cases
+	var read Account
+	err = db.First(&read, account.ID).Error
+	require.NoError(t, err, "An error occurred while reading the account")
+	assert.Equal(t, uint64(2), upsertedRev, "Replacing the row should increment its revision")
+	assert.Equal(t, upsertedRev, read.Rev, "The revision of the replaced row should be read back after the upsert")
+	assert.Equal(t, read.Rev, skippedAccount.Rev, "The revision of a skipped row should be read back")
+	account.Balance = 300
+	err = db.Save(&account).Error
+	assert.NoError(t, err, "Saving the account after the upsert should not cause a revision conflict")
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+func TestVerifiedScope(t *testing.T) {
+
+	// Open connection
+	db, err := OpenServerConnection(t, immudbGorm.Config{})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a new user record
+	var newUser = User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Read the user with verification.
+	var user User
+	err = db.Scopes(immudbGorm.Verified()).First(&user, newUser.ID).Error
+
+	// Test cases
+	require.NoError(t, err, "The record should be verified successfully")
+	assert.Equal(t, newUser.Name, user.Name, "The queried name does not match the defined name")
+}
+
+func TestVerifyReads(t *testing.T) {
+
+	// Open a connection verifying all reads.
+	db, err := OpenServerConnection(t, immudbGorm.Config{VerifyReads: true})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create two new user records
+	err = db.Create(&[]User{{Name: "Jose", Age: 33}, {Name: "Dave", Age: 35}}).Error
+	require.NoError(t, err, "An error occurred while creating the records")
+
+	// Read the users with verification.
+	var users []User
+	err = db.Find(&users).Error
+	require.NoError(t, err, "The records should be verified successfully")
+
+	// Aggregations are not verified, but should still work.
+	var count int64
+	err = db.Model(&User{}).Count(&count).Error
+	require.NoError(t, err, "Counting the records should not fail")
+
+	// Test cases
+	assert.Len(t, users, 2, "Both records should be returned")
+	assert.Equal(t, int64(2), count, "Both records should be counted")
+}
+
+func TestVerifiedUpdatedRow(t *testing.T) {
+
+	// Open connection
+	db, err := OpenServerConnection(t, immudbGorm.Config{})
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+
+	// Create a user and update it, followed by another transaction.
+	var newUser = User{Name: "Jose", Age: 33}
+	err = db.Create(&newUser).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	err = db.Model(&newUser).Update("age", 34).Error
+	require.NoError(t, err, "An error occurred while updating the record")
+	err = db.Create(&User{Name: "Dave", Age: 35}).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Read the user with verification.
+	var user User
+	err = db.Scopes(immudbGorm.Verified()).First(&user, newUser.ID).Error
+
+	// Test cases
+	require.NoError(t, err, "The updated record should be verified successfully")
+	assert.Equal(t, 34, user.Age, "The current value of the record should be read")
+}
+
+func TestVerifiedScopeEmbedded(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a users table
+	err = db.AutoMigrate(&User{})
+	require.NoError(t, err, "There was an error creating users table")
+	err = db.Create(&User{Name: "Jose", Age: 33}).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+
+	// Read the user with verification.
+	var user User
+	err = db.Scopes(immudbGorm.Verified()).First(&user).Error
+
+	// Test cases
+	assert.ErrorIs(t, err, immudbGorm.ErrVerificationUnsupported, "Embedded databases have no immudb client to request proofs")
+}
@@ -0,0 +1,167 @@
+package test_migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+	"gorm.io/gorm"
+)
+
+func TestCurrentDatabase(t *testing.T) {
+
+	// Open connection
+	path := t.TempDir()
+	db, err := gorm.Open(immudbGorm.Open("immudbe://"+path), &gorm.Config{})
+	require.NoError(t, err, "An error ocurred while opening connection")
+
+	// The name of an embedded database is the last element of its path.
+	assert.Equal(t, filepath.Base(path), db.Migrator().CurrentDatabase(), "The current database should be the one of the dsn")
+}
+
+func TestCurrentDatabaseServer(t *testing.T) {
+
+	// Open connection
+	dsn := os.Getenv("IMMUDB_DSN")
+	if dsn == "" {
+		t.Skip("IMMUDB_DSN is not set, the test requires an immudb server")
+	}
+	db, err := gorm.Open(immudbGorm.Open(dsn), &gorm.Config{})
+	require.NoError(t, err, "An error ocurred while opening connection")
+	parsed, err := immudbGorm.ParseDSN(dsn)
+	require.NoError(t, err, "An error ocurred while parsing the dsn")
+
+	// The server reports the database of the session.
+	assert.Equal(t, parsed.Database, db.Migrator().CurrentDatabase(), "The current database should be reported by the server")
+}
+
+func TestUseDatabase(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error ocurred while opening connection")
+	name := db.Migrator().CurrentDatabase()
+
+	// Databases have to exist before they can be used.
+	_, err = immudbGorm.UseDatabase(db, "tenant")
+	assert.Error(t, err, "Switching to a database, which does not exist, should fail")
+	err = db.Migrator().(immudbGorm.Migrator).CreateDatabase("tenant")
+	require.NoError(t, err, "An error occurred while creating a new database")
+
+	// Switch to another database.
+	tenant, err := immudbGorm.UseDatabase(db, "tenant")
+	require.NoError(t, err, "An error ocurred while switching the database")
+	assert.Equal(t, "tenant", tenant.Migrator().CurrentDatabase(), "The session should use the new database")
+	assert.Equal(t, name, db.Migrator().CurrentDatabase(), "The original session should not be changed")
+
+	// Tables of one database should not be visible in the other one.
+	err = tenant.Migrator().CreateTable(&Employee{})
+	require.NoError(t, err, "An error occurred while creating a new table")
+	err = tenant.Create(&Employee{Name: "Jose", Salary: 1000}).Error
+	require.NoError(t, err, "An error occurred while creating a new record")
+	assert.True(t, tenant.Migrator().HasTable(&Employee{}), "Table employees should exist in the new database")
+	assert.False(t, db.Migrator().HasTable(&Employee{}), "Table employees should not exist in the original database")
+
+	// Switching to the same database again should reuse its connections.
+	again, err := immudbGorm.UseDatabase(db, "tenant")
+	require.NoError(t, err, "An error ocurred while switching the database")
+	var employees []Employee
+	err = again.Find(&employees).Error
+	require.NoError(t, err, "An error occurred while reading the records")
+	assert.Len(t, employees, 1, "The record should be readable in the new database")
+
+	// Switching back to the original database.
+	back, err := immudbGorm.UseDatabase(tenant, name)
+	require.NoError(t, err, "An error ocurred while switching the database")
+	assert.Equal(t, name, back.Migrator().CurrentDatabase(), "The session should use the original database")
+	assert.False(t, back.Migrator().HasTable(&Employee{}), "Table employees should not exist in the original database")
+
+	// The database cannot be switched within a transaction.
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := immudbGorm.UseDatabase(tx, "tenant")
+		return err
+	})
+	assert.Error(t, err, "Switching the database within a transaction should fail")
+
+	// Invalid names should be rejected.
+	_, err = immudbGorm.UseDatabase(db, "../other")
+	assert.Error(t, err, "Invalid database names should be rejected")
+}
+
+func TestCreateDatabase(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error ocurred while opening connection")
+
+	// Check if the database exists before creating it
+	migrator := db.Migrator().(immudbGorm.Migrator)
+	assert.False(t, migrator.HasDatabase("tenant"), "Database tenant should not exist before creating it")
+
+	// Create the database
+	err = migrator.CreateDatabase("tenant")
+	require.NoError(t, err, "An error occurred while creating a new database")
+
+	// Check if the database exists after creating it
+	assert.True(t, migrator.HasDatabase("tenant"), "Database tenant should exist after creating it")
+	databases, err := migrator.ListDatabases()
+	require.NoError(t, err, "An error occurred while listing the databases")
+	assert.Contains(t, databases, "tenant", "Database tenant should be listed")
+
+	// The new database should be usable.
+	tenant, err := immudbGorm.UseDatabase(db, "tenant")
+	require.NoError(t, err, "An error ocurred while switching the database")
+	err = tenant.Migrator().CreateTable(&Employee{})
+	assert.NoError(t, err, "An error occurred while creating a new table")
+
+	// Invalid names should be rejected.
+	err = migrator.CreateDatabase("../tenant")
+	assert.Error(t, err, "Invalid database names should be rejected")
+}
+
+func TestDropDatabase(t *testing.T) {
+
+	// Open connection
+	dsn := os.Getenv("IMMUDB_DSN")
+	if dsn == "" {
+		t.Skip("IMMUDB_DSN is not set, the test requires an immudb server")
+	}
+	db, err := gorm.Open(immudbGorm.Open(dsn), &gorm.Config{})
+	require.NoError(t, err, "An error ocurred while opening connection")
+	migrator := db.Migrator().(immudbGorm.Migrator)
+	name := fmt.Sprintf("tenant%d", time.Now().UnixNano())
+	err = migrator.CreateDatabase(name)
+	require.NoError(t, err, "An error occurred while creating a new database")
+
+	// Unload and drop the database.
+	err = migrator.UnloadDatabase(name)
+	require.NoError(t, err, "An error occurred while unloading the database")
+	err = migrator.DropDatabase(name)
+	require.NoError(t, err, "An error occurred while dropping the database")
+
+	// Test cases
+	assert.False(t, migrator.HasDatabase(name), "The database should not exist after dropping it")
+}
+
+func TestDropDatabaseEmbedded(t *testing.T) {
+
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error ocurred while opening connection")
+	migrator := db.Migrator().(immudbGorm.Migrator)
+	err = migrator.CreateDatabase("tenant")
+	require.NoError(t, err, "An error occurred while creating a new database")
+
+	// Embedded databases cannot be managed by an immudb client.
+	unloadErr := migrator.UnloadDatabase("tenant")
+	dropErr := migrator.DropDatabase("tenant")
+
+	// Test cases
+	assert.ErrorIs(t, unloadErr, immudbGorm.ErrDatabaseManagementUnsupported, "Unloading an embedded database should be rejected")
+	assert.ErrorIs(t, dropErr, immudbGorm.ErrDatabaseManagementUnsupported, "Dropping an embedded database should be rejected")
+}
@@ -0,0 +1,90 @@
+package test_migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Account has columns with unique values.
+type Account struct {
+	ID        uint
+	Email     string `gorm:"size:64;unique"`
+	Login     string `gorm:"size:64;uniqueIndex"`
+	FirstName string `gorm:"size:64;index:idx_accounts_name,unique"`
+	LastName  string `gorm:"size:64;index:idx_accounts_name,unique"`
+	Team      string `gorm:"size:64;index"`
+}
+
+// uniqueColumns returns the columns of the unique indexes of a table, except
+// the primary key.
+func uniqueColumns(t *testing.T, db *gorm.DB, value interface{}) [][]string {
+	indexes, err := db.Migrator().GetIndexes(value)
+	require.NoError(t, err, "getting the indexes of an existing table should not cause an error")
+	columns := [][]string{}
+	for _, index := range indexes {
+		primary, _ := index.PrimaryKey()
+		unique, ok := index.Unique()
+		assert.True(t, ok, "checking if an index is unique should never fail")
+		if unique && !primary {
+			columns = append(columns, index.Columns())
+		}
+	}
+	return columns
+}
+
+func TestCreateTableUniqueIndexes(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error ocurred while opening connection")
+
+	// Create an accounts table
+	err = db.Migrator().CreateTable(&Account{})
+	require.NoError(t, err, "creating a table with unique columns should not cause an error")
+
+	// Create accounts with duplicate values
+	err = db.Create(&Account{Email: "a@example.com", Login: "a", FirstName: "Ada", LastName: "Lovelace", Team: "x"}).Error
+	require.NoError(t, err, "creating the first account should not cause an error")
+	duplicateEmail := db.Create(&Account{Email: "a@example.com", Login: "b", FirstName: "Alan", LastName: "Turing", Team: "x"}).Error
+	duplicateLogin := db.Create(&Account{Email: "b@example.com", Login: "a", FirstName: "Alan", LastName: "Turing", Team: "x"}).Error
+	duplicateName := db.Create(&Account{Email: "c@example.com", Login: "c", FirstName: "Ada", LastName: "Lovelace", Team: "x"}).Error
+	other := db.Create(&Account{Email: "d@example.com", Login: "d", FirstName: "Ada", LastName: "Byron", Team: "x"}).Error
+
+	// Test cases
+	assert.ElementsMatch(t, [][]string{{"email"}, {"login"}, {"first_name", "last_name"}}, uniqueColumns(t, db, &Account{}), "unique indexes should be created for all unique columns")
+	assert.Error(t, duplicateEmail, "a duplicate value of a unique column should be rejected")
+	assert.Error(t, duplicateLogin, "a duplicate value of a column with a unique index should be rejected")
+	assert.Error(t, duplicateName, "duplicate values of a unique index of several columns should be rejected")
+	assert.NoError(t, other, "values which are not duplicates should be accepted")
+}
+
+func TestAutoMigrateUniqueIndexes(t *testing.T) {
+	// Open connection
+	db, err := OpenConnection(t)
+	require.NoError(t, err, "An error ocurred while opening connection")
+
+	// Create an accounts table without unique columns.
+	{
+		type Account struct {
+			ID   uint
+			Team string `gorm:"size:64;index"`
+		}
+		err = db.AutoMigrate(&Account{})
+		require.NoError(t, err, "creating a table should not cause an error")
+	}
+
+	// Add unique columns by migrating the table.
+	type Account struct {
+		ID    uint
+		Team  string `gorm:"size:64;index"`
+		Email string `gorm:"size:64;unique"`
+		Login string `gorm:"size:64;uniqueIndex"`
+	}
+	err = db.AutoMigrate(&Account{})
+	require.NoError(t, err, "adding unique columns should not cause an error")
+
+	// Test cases
+	assert.ElementsMatch(t, [][]string{{"email"}, {"login"}}, uniqueColumns(t, db, &Account{}), "unique indexes should be created for the added columns")
+}
@@ -0,0 +1,48 @@
+package test_verification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+func TestVerifyDatabase(t *testing.T) {
+
+	// Open connection
+	store, err := immudbGorm.NewFileStateStore(t.TempDir())
+	require.NoError(t, err, "There was an error creating the state store")
+	db, err := OpenServerConnection(t, store)
+	require.NoError(t, err, "There was an error opening connection")
+
+	// Create a documents table with two rows.
+	err = db.AutoMigrate(&Document{})
+	require.NoError(t, err, "There was an error creating documents table")
+	err = db.Create(&[]Document{{Title: "Contract", Pages: 3}, {Title: "Invoice", Pages: 1}}).Error
+	require.NoError(t, err, "There was an error creating the documents")
+
+	// Verify the whole database.
+	report, err := immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+
+	// Test cases
+	assert.True(t, report.OK(), "The database should be verified successfully")
+	require.NotNil(t, report.State, "The trusted state should be reported")
+	require.Len(t, report.Tables, 1, "The documents table should be verified")
+	assert.Equal(t, "documents", report.Tables[0].Table, "The documents table should be verified")
+	assert.Equal(t, 2, report.Tables[0].Rows, "Both documents should be verified")
+
+	firstState := report.State
+
+	// A second verification continues from the trusted state.
+	err = db.Create(&Document{Title: "Receipt", Pages: 1}).Error
+	require.NoError(t, err, "There was an error creating a document")
+	report, err = immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+
+	// Test cases
+	assert.True(t, report.OK(), "The database should still be consistent with the trusted state")
+	assert.Greater(t, report.State.TxID, firstState.TxID, "The trusted state should advance")
+	assert.Equal(t, 3, report.Tables[0].Rows, "All documents should be verified")
+}
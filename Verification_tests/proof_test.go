@@ -0,0 +1,179 @@
+package test_verification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/logger"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+// proofDatabase is an immudb database, which provides the entries proofs
+// are created from the same way the server does.
+type proofDatabase struct {
+	t   *testing.T
+	db  database.DB
+	key *ecdsa.PrivateKey
+}
+
+// openProofDatabase creates a database with a customers table in a temporary
+// directory. The states of the database are signed with a new key.
+func openProofDatabase(t *testing.T) *proofDatabase {
+	options := database.DefaultOptions().WithDBRootPath(t.TempDir())
+	db, err := database.NewDB("defaultdb", nil, options, logger.NewSimpleLogger("immudb ", io.Discard))
+	require.NoError(t, err, "There was an error creating the database")
+	t.Cleanup(func() { db.Close() })
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "There was an error generating a key")
+
+	d := &proofDatabase{t: t, db: db, key: key}
+	d.exec("CREATE TABLE customers (id INTEGER AUTO_INCREMENT, name VARCHAR[64], deleted_at TIMESTAMP, PRIMARY KEY id)")
+	return d
+}
+
+// exec executes a statement in its own transaction.
+func (d *proofDatabase) exec(sql string) {
+	_, _, err := d.db.SQLExec(context.Background(), nil, &schema.SQLExecRequest{Sql: sql})
+	require.NoError(d.t, err, "There was an error executing %s", sql)
+}
+
+// prove creates a proof for the customer with id, which leads to the current
+// state of the database.
+func (d *proofDatabase) prove(id int64) *immudbGorm.Proof {
+	state, err := d.db.CurrentState()
+	require.NoError(d.t, err, "There was an error reading the current state")
+	entry, err := d.db.VerifiableSQLGet(context.Background(), &schema.VerifiableSQLGetRequest{
+		SqlGetRequest: &schema.SQLGetRequest{Table: "customers", PkValues: []*schema.SQLValue{{Value: &schema.SQLValue_N{N: id}}}},
+		ProveSinceTx:  state.TxId,
+	})
+	require.NoError(d.t, err, "There was an error reading the customer %d", id)
+
+	// Sign the state the proof leads to like the server does.
+	target := schema.TxHeaderFromProto(entry.VerifiableTx.DualProof.TargetTxHeader)
+	alh := target.Alh()
+	signed := &schema.ImmutableState{Db: d.db.GetName(), TxId: target.ID, TxHash: alh[:]}
+	signature, publicKey, err := signer.NewSignerFromPKey(rand.Reader, d.key).Sign(signed.ToBytes())
+	require.NoError(d.t, err, "There was an error signing the state")
+	entry.VerifiableTx.Signature = &schema.Signature{Signature: signature, PublicKey: publicKey}
+
+	proof, err := immudbGorm.NewProof(d.db.GetName(), "customers", entry)
+	require.NoError(d.t, err, "There was an error creating the proof")
+	return proof
+}
+
+func TestVerifyProof(t *testing.T) {
+
+	// Create a customer and further transactions after it.
+	d := openProofDatabase(t)
+	d.exec("INSERT INTO customers (name) VALUES ('Jose')")
+	d.exec("INSERT INTO customers (name) VALUES ('Maria')")
+	d.exec("UPDATE customers SET name = 'Ana' WHERE id = 2")
+	proof := d.prove(1)
+
+	// The proof should survive a JSON round trip.
+	data, err := json.Marshal(proof)
+	require.NoError(t, err, "There was an error serializing the proof")
+	decoded := &immudbGorm.Proof{}
+	err = json.Unmarshal(data, decoded)
+	require.NoError(t, err, "There was an error deserializing the proof")
+
+	// Test cases
+	require.NotNil(t, decoded.Dual, "The transaction of the customer should be linked to the current state")
+	assert.Less(t, decoded.Tx.ID, decoded.State.TxID, "The proof should lead to the current state")
+	assert.NoError(t, immudbGorm.VerifyProof(decoded, &d.key.PublicKey), "A valid proof should be accepted")
+	assert.NoError(t, immudbGorm.VerifyProof(decoded, nil), "A valid proof should be accepted without checking the signature")
+
+	values, err := decoded.Values()
+	require.NoError(t, err, "There was an error decoding the values of the row")
+	assert.Equal(t, int64(1), values["id"], "The id of the row should be decoded")
+	assert.Equal(t, "Jose", values["name"], "The name of the row should be decoded")
+	assert.Nil(t, values["deleted_at"], "Null values should be decoded as nil")
+
+	// A row changed by the last transaction is proven by the state at it.
+	proof = d.prove(2)
+	assert.Nil(t, proof.Dual, "The state at the transaction of the row should not need a dual proof")
+	assert.NoError(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), "A valid proof of the last transaction should be accepted")
+	values, err = proof.Values()
+	require.NoError(t, err, "There was an error decoding the values of the row")
+	assert.Equal(t, "Ana", values["name"], "The current value of the row should be proven")
+}
+
+func TestVerifyTamperedProof(t *testing.T) {
+
+	d := openProofDatabase(t)
+	d.exec("INSERT INTO customers (name) VALUES ('Jose')")
+	d.exec("INSERT INTO customers (name) VALUES ('Maria')")
+	d.exec("INSERT INTO customers (name) VALUES ('Joel')")
+	valid := d.prove(1)
+	require.NoError(t, immudbGorm.VerifyProof(valid, &d.key.PublicKey), "The untampered proof should be accepted")
+
+	// tampered returns a copy of the valid proof.
+	tampered := func() *immudbGorm.Proof {
+		data, err := json.Marshal(valid)
+		require.NoError(t, err, "There was an error serializing the proof")
+		proof := &immudbGorm.Proof{}
+		require.NoError(t, json.Unmarshal(data, proof), "There was an error deserializing the proof")
+		return proof
+	}
+
+	// The value of another row.
+	other := d.prove(3)
+	proof := tampered()
+	proof.Entry.Value = other.Entry.Value
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "The value of another row should be rejected")
+
+	// The key of another row.
+	proof = tampered()
+	proof.Entry.Key = other.Entry.Key
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "The key of another row should be rejected")
+
+	// The entry of another row.
+	proof = tampered()
+	proof.Entry = other.Entry
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "The entry of another row should be rejected")
+
+	// Another table.
+	proof = tampered()
+	proof.TableID++
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "Another table should be rejected")
+
+	// Another primary key column.
+	proof = tampered()
+	proof.PrimaryKey = []uint32{2}
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "Another primary key should be rejected")
+
+	// Deleted metadata added to the entry.
+	proof = tampered()
+	proof.Entry.Metadata = &immudbGorm.EntryMetadata{Deleted: true}
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "Modified metadata should be rejected")
+
+	// A modified transaction.
+	proof = tampered()
+	proof.Tx.Ts++
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "A modified transaction should be rejected")
+
+	// A missing link between the transaction and the state.
+	proof = tampered()
+	proof.Dual = nil
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, &d.key.PublicKey), immudbGorm.ErrInvalidProof, "A proof without dual proof should be rejected")
+
+	// A state, which is not part of the history.
+	proof = tampered()
+	proof.State.TxHash = other.Tx.Eh
+	assert.ErrorIs(t, immudbGorm.VerifyProof(proof, nil), immudbGorm.ErrInvalidProof, "A modified state should be rejected")
+
+	// A state signed by another key.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "There was an error generating a key")
+	assert.ErrorIs(t, immudbGorm.VerifyProof(tampered(), &key.PublicKey), immudbGorm.ErrInvalidProof, "A state signed by another key should be rejected")
+}
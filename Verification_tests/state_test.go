@@ -0,0 +1,143 @@
+package test_verification
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	immudbGorm "github.com/tauu/immudb-gorm"
+)
+
+func TestFileStateStore(t *testing.T) {
+
+	// Create a store in a temporary directory.
+	store, err := immudbGorm.NewFileStateStore(t.TempDir())
+	require.NoError(t, err, "There was an error creating the state store")
+
+	// No state should be stored initially.
+	state, err := store.Load("immudb://localhost:3322/test")
+	require.NoError(t, err, "There was an error loading a state")
+	assert.Nil(t, state, "No state should be stored before the first verification")
+
+	// Store a state and load it again.
+	trusted := &immudbGorm.State{Database: "test", TxID: 42, TxHash: []byte{1, 2, 3}}
+	err = store.Store("immudb://localhost:3322/test", trusted)
+	require.NoError(t, err, "There was an error storing a state")
+	state, err = store.Load("immudb://localhost:3322/test")
+	require.NoError(t, err, "There was an error loading a state")
+
+	// Test cases
+	assert.Equal(t, trusted, state, "The loaded state should match the stored one")
+
+	// States of other databases should not be affected.
+	state, err = store.Load("immudb://localhost:3322/other")
+	require.NoError(t, err, "There was an error loading a state")
+	assert.Nil(t, state, "The state of another database should not be returned")
+}
+
+func TestFileStateStoreReopen(t *testing.T) {
+
+	dir := t.TempDir()
+
+	// Store a state with one store.
+	store, err := immudbGorm.NewFileStateStore(dir)
+	require.NoError(t, err, "There was an error creating the state store")
+	trusted := &immudbGorm.State{Database: "test", TxID: 7, TxHash: []byte{4, 5, 6}}
+	err = store.Store("test", trusted)
+	require.NoError(t, err, "There was an error storing a state")
+
+	// Load the state with a new store, simulating a restart.
+	reopened, err := immudbGorm.NewFileStateStore(dir)
+	require.NoError(t, err, "There was an error reopening the state store")
+	state, err := reopened.Load("test")
+	require.NoError(t, err, "There was an error loading a state")
+
+	// Test cases
+	assert.Equal(t, trusted, state, "The state should survive a restart")
+}
+
+// memoryStateStore keeps a single trusted state in memory.
+type memoryStateStore struct {
+	state *immudbGorm.State
+}
+
+func (store *memoryStateStore) Load(key string) (*immudbGorm.State, error) {
+	return store.state, nil
+}
+
+func (store *memoryStateStore) Store(key string, state *immudbGorm.State) error {
+	store.state = state
+	return nil
+}
+
+func TestHistoryRewritten(t *testing.T) {
+
+	// A trusted state newer than the current state of the database.
+	store := &memoryStateStore{state: &immudbGorm.State{TxID: 1 << 40, TxHash: make([]byte, 32)}}
+	db, err := OpenServerConnection(t, store)
+	require.NoError(t, err, "There was an error opening connection")
+	report, err := immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+
+	// Test cases
+	assert.ErrorIs(t, report.StateErr, immudbGorm.ErrHistoryRewritten, "A database behind the trusted state should be rejected")
+	assert.False(t, report.OK(), "A database behind the trusted state should not be verified")
+	assert.Equal(t, uint64(1<<40), store.state.TxID, "The trusted state should not be replaced")
+}
+
+func TestHistoryRewrittenHash(t *testing.T) {
+
+	// Trust the current state of a database.
+	name := fmt.Sprintf("test%d", time.Now().UnixNano())
+	store := &memoryStateStore{}
+	db, err := OpenServerDatabase(t, name, store)
+	require.NoError(t, err, "There was an error opening connection")
+	report, err := immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+	require.True(t, report.OK(), "The database should be verified successfully")
+
+	// Connect again with a trusted state of the same transaction, but with
+	// another hash.
+	tampered := *store.state
+	tampered.TxHash = append([]byte{}, tampered.TxHash...)
+	tampered.TxHash[0] ^= 0xff
+	db, err = OpenServerDatabase(t, name, &memoryStateStore{state: &tampered})
+	require.NoError(t, err, "There was an error opening connection")
+	report, err = immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+
+	// Test cases
+	assert.ErrorIs(t, report.StateErr, immudbGorm.ErrHistoryRewritten, "A database with another history should be rejected")
+}
+
+func TestVerifyStateWithoutStore(t *testing.T) {
+
+	// Without a store, the state is trusted on first use and kept in memory.
+	db, err := OpenServerConnection(t, nil)
+	require.NoError(t, err, "There was an error opening connection")
+	report, err := immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+	require.NotNil(t, report.State, "The trusted state should be reported")
+	first := report.State
+
+	err = db.AutoMigrate(&Document{})
+	require.NoError(t, err, "There was an error creating documents table")
+	report, err = immudbGorm.VerifyDatabase(db)
+	require.NoError(t, err, "There was an error verifying the database")
+
+	// Test cases
+	assert.NoError(t, report.StateErr, "The database should be consistent with the state trusted before")
+	assert.Greater(t, report.State.TxID, first.TxID, "The trusted state should advance")
+}
+
+func TestVerifyStateEmbedded(t *testing.T) {
+
+	db, err := OpenConnection(t, nil)
+	require.NoError(t, err, "There was an error opening connection")
+	_, err = immudbGorm.VerifyDatabase(db)
+
+	// Test cases
+	assert.ErrorIs(t, err, immudbGorm.ErrVerificationUnsupported, "Embedded databases have no immudb client to verify their state")
+}
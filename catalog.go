@@ -0,0 +1,113 @@
+package immudbGorm
+
+import (
+	"fmt"
+	"sync"
+)
+
+// pinnedCatalog contains the ids immudb assigned to the tables and columns
+// of a database, which have been read with verification. A proof binds the
+// values of a row to the ids of its columns and the row itself to the id of
+// its table, but the names of the table and the columns are taken from the
+// catalog of the server and are not covered by the proof. The ids are
+// therefore pinned on first use, a server answering with the row of another
+// table or swapping columns afterwards is detected.
+//
+// The catalog is only kept in memory. Renaming or recreating a table or a
+// column, which has been pinned, fails the verification until the
+// application is restarted.
+type pinnedCatalog struct {
+	mu     sync.Mutex
+	tables map[string]*pinnedTable
+}
+
+// pinnedTable contains the pinned ids of a table and its columns.
+type pinnedTable struct {
+	databaseID uint32
+	tableID    uint32
+	primaryKey []uint32
+	// columns contains the pinned columns by their names.
+	columns map[string]ProofColumn
+	// maxColumnID is the largest id assigned to a column when the table
+	// was pinned last. Columns added afterwards get larger ids.
+	maxColumnID uint32
+}
+
+// check verifies that the table and the columns of a proof match the pinned
+// ones. If the table has not been pinned yet, it is pinned with the ids of
+// the proof. Columns added to the table after it has been pinned are pinned
+// as well.
+func (catalog *pinnedCatalog) check(proof *Proof) error {
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+	if catalog.tables == nil {
+		catalog.tables = map[string]*pinnedTable{}
+	}
+	table, ok := catalog.tables[proof.Table]
+	if !ok {
+		table = &pinnedTable{
+			databaseID:  proof.DatabaseID,
+			tableID:     proof.TableID,
+			primaryKey:  append([]uint32(nil), proof.PrimaryKey...),
+			columns:     make(map[string]ProofColumn, len(proof.Columns)),
+			maxColumnID: proof.MaxColumnID,
+		}
+		ids := make(map[uint32]bool, len(proof.Columns))
+		for _, column := range proof.Columns {
+			if column.ID > proof.MaxColumnID {
+				return fmt.Errorf("%w: the id %d of column %s is larger than the largest id of table %s", ErrInvalidProof, column.ID, column.Name, proof.Table)
+			}
+			if _, ok := table.columns[column.Name]; ok || ids[column.ID] {
+				return fmt.Errorf("%w: the column %s of table %s is described twice", ErrInvalidProof, column.Name, proof.Table)
+			}
+			ids[column.ID] = true
+			table.columns[column.Name] = column
+		}
+		catalog.tables[proof.Table] = table
+		return nil
+	}
+
+	if proof.DatabaseID != table.databaseID || proof.TableID != table.tableID {
+		return fmt.Errorf("%w: the row is not part of the table %s read so far", ErrInvalidProof, proof.Table)
+	}
+	if len(proof.PrimaryKey) != len(table.primaryKey) {
+		return fmt.Errorf("%w: the primary key of table %s has changed", ErrInvalidProof, proof.Table)
+	}
+	for i, id := range table.primaryKey {
+		if proof.PrimaryKey[i] != id {
+			return fmt.Errorf("%w: the primary key of table %s has changed", ErrInvalidProof, proof.Table)
+		}
+	}
+	if proof.MaxColumnID < table.maxColumnID {
+		return fmt.Errorf("%w: the ids of the columns of table %s have been reassigned", ErrInvalidProof, proof.Table)
+	}
+
+	added := []ProofColumn{}
+	for _, column := range proof.Columns {
+		pinned, ok := table.columns[column.Name]
+		if !ok {
+			// Columns added after the table has been pinned cannot reuse
+			// the id of a column known already.
+			if column.ID <= table.maxColumnID || column.ID > proof.MaxColumnID {
+				return fmt.Errorf("%w: the column %s of table %s has an id of another column", ErrInvalidProof, column.Name, proof.Table)
+			}
+			added = append(added, column)
+			continue
+		}
+		if column != pinned {
+			return fmt.Errorf("%w: the column %s of table %s does not match the one read so far", ErrInvalidProof, column.Name, proof.Table)
+		}
+	}
+	for _, column := range added {
+		for _, other := range added {
+			if other.ID == column.ID && other.Name != column.Name {
+				return fmt.Errorf("%w: the columns %s and %s of table %s have the same id", ErrInvalidProof, column.Name, other.Name, proof.Table)
+			}
+		}
+	}
+	for _, column := range added {
+		table.columns[column.Name] = column
+	}
+	table.maxColumnID = proof.MaxColumnID
+	return nil
+}
@@ -0,0 +1,91 @@
+package immudbGorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogProof returns a proof for a row of the customers table, which only
+// describes the table and its columns.
+func catalogProof() *Proof {
+	return &Proof{
+		Table:      "customers",
+		DatabaseID: 1,
+		TableID:    3,
+		Columns: []ProofColumn{
+			{Name: "id", ID: 1, Type: "INTEGER"},
+			{Name: "name", ID: 2, Type: "VARCHAR", MaxLength: 64},
+			{Name: "city", ID: 3, Type: "VARCHAR", MaxLength: 64},
+		},
+		PrimaryKey:  []uint32{1},
+		MaxColumnID: 3,
+	}
+}
+
+func TestPinnedCatalog(t *testing.T) {
+
+	// The first proof of a table pins its ids.
+	catalog := &pinnedCatalog{}
+	require.NoError(t, catalog.check(catalogProof()), "The first proof of a table should be trusted")
+	assert.NoError(t, catalog.check(catalogProof()), "A proof matching the pinned table should be accepted")
+
+	// A column added after the table has been pinned.
+	proof := catalogProof()
+	proof.Columns = append(proof.Columns, ProofColumn{Name: "email", ID: 5, Type: "VARCHAR"})
+	proof.MaxColumnID = 5
+	require.NoError(t, catalog.check(proof), "A column added to the table should be accepted")
+	assert.NoError(t, catalog.check(proof), "The added column should be pinned")
+
+	// A column dropped from the table.
+	proof = catalogProof()
+	proof.Columns = proof.Columns[:2]
+	proof.MaxColumnID = 5
+	assert.NoError(t, catalog.check(proof), "A column dropped from the table should be accepted")
+}
+
+func TestPinnedCatalogTampered(t *testing.T) {
+
+	catalog := &pinnedCatalog{}
+	require.NoError(t, catalog.check(catalogProof()), "The first proof of a table should be trusted")
+
+	// A row of another table.
+	proof := catalogProof()
+	proof.TableID = 4
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "A row of another table should be rejected")
+
+	// A row of another database.
+	proof = catalogProof()
+	proof.DatabaseID = 2
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "A row of another database should be rejected")
+
+	// Two columns of the same type swapped.
+	proof = catalogProof()
+	proof.Columns[1].Name, proof.Columns[2].Name = proof.Columns[2].Name, proof.Columns[1].Name
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "Swapped columns should be rejected")
+
+	// A column with another type.
+	proof = catalogProof()
+	proof.Columns[1].Type = "BLOB"
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "A column with another type should be rejected")
+
+	// Another primary key.
+	proof = catalogProof()
+	proof.PrimaryKey = []uint32{2}
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "Another primary key should be rejected")
+
+	// A new column reusing the id of a pinned one.
+	proof = catalogProof()
+	proof.Columns[2].Name = "email"
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "A new column with the id of a pinned one should be rejected")
+
+	// Two new columns with the same id.
+	proof = catalogProof()
+	proof.Columns = append(proof.Columns, ProofColumn{Name: "email", ID: 4, Type: "VARCHAR"}, ProofColumn{Name: "phone", ID: 4, Type: "VARCHAR"})
+	proof.MaxColumnID = 4
+	assert.ErrorIs(t, catalog.check(proof), ErrInvalidProof, "New columns sharing an id should be rejected")
+
+	// None of the rejected proofs should have changed the pins.
+	assert.NoError(t, catalog.check(catalogProof()), "The pinned table should be unchanged")
+}
@@ -0,0 +1,111 @@
+package immudbGorm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gorm.io/gorm"
+)
+
+// clientSession provides the immudb client of a database. immudb SQL has no
+// statements for verification, proofs, the current state or the management
+// of databases, these features use the gRPC API of the server instead.
+type clientSession struct {
+	// dsn describes the database the session is opened for. It is nil if
+	// the client has been provided by the application.
+	dsn *DSN
+
+	mu     sync.Mutex
+	client client.ImmuClient
+}
+
+// newClientSession creates the client session of a dialector. If the
+// application provided a client, it is used as it is. Otherwise a session
+// is opened with the credentials of the dsn on first use. Embedded
+// databases and connection pools provided by the application without a
+// client have no session.
+func newClientSession(config *Config) *clientSession {
+	if config.Client != nil {
+		return &clientSession{client: config.Client}
+	}
+	if config.Conn != nil || config.DriverName != "immudb" {
+		return nil
+	}
+	dsn, err := ParseDSN(config.DSN)
+	if err != nil || dsn.Embedded {
+		return nil
+	}
+	return &clientSession{dsn: dsn}
+}
+
+// get returns the client of the session. The session is opened if it is
+// used for the first time.
+func (s *clientSession) get(ctx context.Context) (client.ImmuClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	options := client.DefaultOptions().WithAddress(s.dsn.Host)
+	if s.dsn.Port != 0 {
+		options = options.WithPort(s.dsn.Port)
+	}
+	// The trusted state is tracked by the dialector, the client must not
+	// keep its own state files in the working directory.
+	options = options.WithDisableIdentityCheck(true)
+	if s.dsn.TLS {
+		config := &tls.Config{ServerName: s.dsn.Host}
+		if s.dsn.CACert != "" {
+			pem, err := os.ReadFile(s.dsn.CACert)
+			if err != nil {
+				return nil, err
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("the CA certificate %s does not contain a valid certificate", s.dsn.CACert)
+			}
+		}
+		options = options.WithDialOptions([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(config))})
+	}
+
+	c := client.NewClient().WithOptions(options)
+	if err := c.OpenSession(ctx, []byte(s.dsn.User), []byte(s.dsn.Password), s.dsn.Database); err != nil {
+		return nil, err
+	}
+	s.client = c
+	return c, nil
+}
+
+// Close closes the session, if it has been opened by the dialector. Clients
+// provided by the application are left open.
+func (s *clientSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dsn == nil || s.client == nil {
+		return nil
+	}
+	err := s.client.CloseSession(context.Background())
+	s.client = nil
+	return err
+}
+
+// immuClientOf returns the immudb client of the database a session is bound
+// to. If no client is available, unsupported is returned as error.
+func immuClientOf(db *gorm.DB, unsupported error) (client.ImmuClient, error) {
+	d, ok := db.Dialector.(*dialector)
+	if !ok {
+		return nil, fmt.Errorf("the database does not use the immudb dialector")
+	}
+	if d.session == nil {
+		return nil, unsupported
+	}
+	return d.session.get(db.Statement.Context)
+}
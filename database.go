@@ -0,0 +1,284 @@
+package immudbGorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDatabaseManagementUnsupported is returned by DropDatabase and
+// UnloadDatabase, if no immudb client is available, e.g. for embedded
+// databases. immudb SQL does not provide statements for these operations.
+var ErrDatabaseManagementUnsupported = errors.New("dropping or unloading databases requires an immudb client")
+
+// errCurrentDatabaseUnsupported is returned if the database a session uses
+// cannot be determined, as no immudb client is available.
+var errCurrentDatabaseUnsupported = errors.New("the current database can only be determined with an immudb client")
+
+// CreateDatabase creates a new database on the immudb server.
+func (m Migrator) CreateDatabase(name string) error {
+	if err := validateDatabaseName(name); err != nil {
+		return err
+	}
+	return m.DB.Session(&gorm.Session{NewDB: true}).Exec("CREATE DATABASE ?", clause.Table{Name: name}).Error
+}
+
+// HasDatabase determines if a database with a specific name exists.
+func (m Migrator) HasDatabase(name string) bool {
+	var count int64
+	err := m.DB.Session(&gorm.Session{NewDB: true}).Raw("SELECT COUNT(*) FROM DATABASES() WHERE name = ?", name).Scan(&count).Error
+	if err != nil {
+		log.Printf("error checking if a database exists: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+// ListDatabases returns the names of all databases the user has access to.
+func (m Migrator) ListDatabases() ([]string, error) {
+	databases := []string{}
+	// Retrieve the database connector.
+	db, err := m.DB.DB()
+	if err != nil {
+		return databases, err
+	}
+	// Query all databases.
+	rows, err := db.Query("SELECT name FROM DATABASES()")
+	if err != nil {
+		return databases, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return databases, err
+		}
+		databases = append(databases, name)
+	}
+	return databases, rows.Err()
+}
+
+// DropDatabase deletes a database and all of its data from the immudb
+// server. Connections opened by UseDatabase for it are closed. The database
+// has to be unloaded first.
+//
+// The database is dropped with the immudb client, as immudb SQL does not
+// provide a statement for dropping databases.
+func (m Migrator) DropDatabase(name string) error {
+	return m.manageDatabase(name, func(ctx context.Context, c client.ImmuClient) error {
+		_, err := c.DeleteDatabase(ctx, &schema.DeleteDatabaseRequest{Database: name})
+		return err
+	})
+}
+
+// UnloadDatabase unloads a database on the immudb server. Its data is kept,
+// but it cannot be used until it is loaded again. Connections opened by
+// UseDatabase for it are closed.
+//
+// The database is unloaded with the immudb client, as immudb SQL does not
+// provide a statement for unloading databases.
+func (m Migrator) UnloadDatabase(name string) error {
+	return m.manageDatabase(name, func(ctx context.Context, c client.ImmuClient) error {
+		_, err := c.UnloadDatabase(ctx, &schema.UnloadDatabaseRequest{Database: name})
+		return err
+	})
+}
+
+// manageDatabase performs an operation of the immudb client on a database.
+func (m Migrator) manageDatabase(name string, operation func(context.Context, client.ImmuClient) error) error {
+	if err := validateDatabaseName(name); err != nil {
+		return err
+	}
+	c, err := immuClientOf(m.DB, ErrDatabaseManagementUnsupported)
+	if err != nil {
+		return err
+	}
+	if err := operation(m.DB.Statement.Context, c); err != nil {
+		return err
+	}
+	if d, ok := m.DB.Dialector.(*dialector); ok {
+		return d.databases.close(name)
+	}
+	return nil
+}
+
+// UseDatabase returns a session, which uses the database name instead of the
+// database the session of db is bound to. The database has to be on the same
+// immudb server, or for embedded databases in the same directory, and is
+// accessed with the same credentials and options.
+//
+// The connections to each database are opened once and shared by all
+// sessions using it. UseDatabase can therefore be called for every request of
+// e.g. a multi-tenant service.
+func UseDatabase(db *gorm.DB, name string) (*gorm.DB, error) {
+	d, ok := db.Dialector.(*dialector)
+	if !ok {
+		return nil, fmt.Errorf("the database does not use the immudb dialector")
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return nil, fmt.Errorf("the database cannot be changed within a transaction")
+	}
+	target, pool, err := d.databases.get(db, name)
+	if err != nil {
+		return nil, err
+	}
+	tx := db.Session(&gorm.Session{Context: db.Statement.Context})
+	tx.Config.Dialector = target
+	tx.Config.ConnPool = pool
+	tx.Statement.ConnPool = pool
+	return tx, nil
+}
+
+// databaseCache contains the dialectors and connection pools of the databases
+// used with UseDatabase. It is shared by the dialector opened by the
+// application and all dialectors derived from it.
+type databaseCache struct {
+	// root is the dialector opened by the application.
+	root *dialector
+	// rootPool is the connection pool of the root dialector.
+	rootPool gorm.ConnPool
+
+	mu         sync.Mutex
+	dialectors map[string]*dialector
+}
+
+// get returns the dialector and connection pool of the database name. They
+// are created on first use.
+func (cache *databaseCache) get(db *gorm.DB, name string) (*dialector, gorm.ConnPool, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if d, ok := cache.dialectors[name]; ok {
+		return d, d.Conn, nil
+	}
+
+	root := cache.root
+	if root.Conn != nil || root.DriverName != "immudb" {
+		return nil, nil, fmt.Errorf("the database can only be changed if the dialector opens the connections for an immudb dsn itself")
+	}
+	if err := validateDatabaseName(name); err != nil {
+		return nil, nil, err
+	}
+	dsn, err := ParseDSN(root.DSN)
+	if err != nil {
+		return nil, nil, err
+	}
+	if databaseOfDSN(dsn) == name {
+		return root, cache.rootPool, nil
+	}
+	sqlDB, ok := cache.rootPool.(*sql.DB)
+	if !ok {
+		return nil, nil, gorm.ErrInvalidDB
+	}
+
+	// Databases have to be created explicitly, connecting to an unknown one
+	// should fail right away instead of at the first query.
+	if dsn.Embedded {
+		dsn.Path = path.Join(path.Dir(dsn.Path), name)
+		if info, err := os.Stat(dsn.Path); err != nil || !info.IsDir() {
+			return nil, nil, fmt.Errorf("the database %s does not exist", name)
+		}
+	} else {
+		var count int64
+		err := db.Session(&gorm.Session{NewDB: true}).Raw("SELECT COUNT(*) FROM DATABASES() WHERE name = ?", name).Scan(&count).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		if count == 0 {
+			return nil, nil, fmt.Errorf("the database %s does not exist", name)
+		}
+		dsn.Database = name
+	}
+
+	// A client of the application is bound to the database of the root
+	// dialector, a session for this database is opened on first use.
+	config := *root.Config
+	config.DSN = dsn.String()
+	config.Client = nil
+	session := newClientSession(&config)
+	connector, err := newTxIDConnector(sqlDB.Driver(), dsn.driverString(), session)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.Conn = sql.OpenDB(connector)
+	d := newDialector(&config)
+	d.session = session
+	d.databases = cache
+	if cache.dialectors == nil {
+		cache.dialectors = map[string]*dialector{}
+	}
+	cache.dialectors[name] = d
+	return d, d.Conn, nil
+}
+
+// close closes the connections opened for the database name and removes it
+// from the cache.
+func (cache *databaseCache) close(name string) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	d, ok := cache.dialectors[name]
+	if !ok {
+		return nil
+	}
+	delete(cache.dialectors, name)
+	if sqlDB, ok := d.Conn.(*sql.DB); ok {
+		return sqlDB.Close()
+	}
+	return nil
+}
+
+// validateDatabaseName checks that name can be used as the name of a
+// database, which is also the name of a directory for embedded databases.
+func validateDatabaseName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("%q is not a valid database name", name)
+	}
+	return nil
+}
+
+// databaseOf returns the name of the database a session is bound to. It is
+// reported by the server for the session of the immudb client. Embedded
+// databases are not managed by a server, their name is the last element of
+// their path.
+func databaseOf(db *gorm.DB) (string, error) {
+	d, ok := db.Dialector.(*dialector)
+	if !ok {
+		return "", fmt.Errorf("the database does not use the immudb dialector")
+	}
+	if d.session == nil {
+		if d.Conn == nil && d.DriverName == "immudb" {
+			if dsn, err := ParseDSN(d.DSN); err == nil && dsn.Embedded {
+				return databaseOfDSN(dsn), nil
+			}
+		}
+		return "", errCurrentDatabaseUnsupported
+	}
+	c, err := d.session.get(db.Statement.Context)
+	if err != nil {
+		return "", err
+	}
+	state, err := c.CurrentState(db.Statement.Context)
+	if err != nil {
+		return "", err
+	}
+	return state.Db, nil
+}
+
+// databaseOfDSN returns the name of the database described by dsn. The name
+// of an embedded database is the last element of its path.
+func databaseOfDSN(dsn *DSN) string {
+	if dsn.Embedded {
+		return path.Base(dsn.Path)
+	}
+	return dsn.Database
+}
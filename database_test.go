@@ -0,0 +1,78 @@
+package immudbGorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// databaseClient is an immudb client of a server, which records the
+// databases deleted and unloaded with it.
+type databaseClient struct {
+	stateClient
+	deleted  []string
+	unloaded []string
+}
+
+func (c *databaseClient) DeleteDatabase(ctx context.Context, r *schema.DeleteDatabaseRequest) (*schema.DeleteDatabaseResponse, error) {
+	c.deleted = append(c.deleted, r.Database)
+	return &schema.DeleteDatabaseResponse{Database: r.Database}, nil
+}
+
+func (c *databaseClient) UnloadDatabase(ctx context.Context, r *schema.UnloadDatabaseRequest) (*schema.UnloadDatabaseResponse, error) {
+	c.unloaded = append(c.unloaded, r.Database)
+	return &schema.UnloadDatabaseResponse{Database: r.Database}, nil
+}
+
+func TestCurrentDatabaseOfClient(t *testing.T) {
+
+	// The server reports the database of the session of the client.
+	c := &stateClient{state: &schema.ImmutableState{Db: "tenant", TxId: 1}}
+	db := sessionOf(&Config{Client: c})
+
+	// Test cases
+	name, err := databaseOf(db)
+	require.NoError(t, err, "There was an error determining the current database")
+	assert.Equal(t, "tenant", name, "The current database should be the one reported by the server")
+	assert.Equal(t, "tenant", db.Migrator().CurrentDatabase(), "The migrator should report the database of the server")
+}
+
+func TestCurrentDatabaseEmbedded(t *testing.T) {
+
+	// Test cases
+	name, err := databaseOf(sessionOf(&Config{DriverName: "immudb", DSN: "immudbe:///var/lib/immudb/tenant"}))
+	require.NoError(t, err, "There was an error determining the current database")
+	assert.Equal(t, "tenant", name, "The name of an embedded database should be the last element of its path")
+	_, err = databaseOf(sessionOf(&Config{DriverName: "other", DSN: "anything"}))
+	assert.Error(t, err, "The database of another driver cannot be determined")
+}
+
+func TestManageDatabase(t *testing.T) {
+
+	c := &databaseClient{}
+	m := sessionOf(&Config{Client: c}).Migrator().(Migrator)
+
+	// Test cases
+	require.NoError(t, m.UnloadDatabase("tenant"), "There was an error unloading the database")
+	require.NoError(t, m.DropDatabase("tenant"), "There was an error dropping the database")
+	assert.Equal(t, []string{"tenant"}, c.unloaded, "The database should be unloaded with the client")
+	assert.Equal(t, []string{"tenant"}, c.deleted, "The database should be deleted with the client")
+
+	// Invalid names are rejected before the server is asked.
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		assert.Error(t, m.DropDatabase(name), "The database name %q should be rejected", name)
+	}
+	assert.Len(t, c.deleted, 1, "No database should be deleted for an invalid name")
+}
+
+func TestManageDatabaseWithoutClient(t *testing.T) {
+
+	m := sessionOf(&Config{DriverName: "immudb", DSN: "immudbe:///tmp/test"}).Migrator().(Migrator)
+
+	// Test cases
+	assert.ErrorIs(t, m.DropDatabase("tenant"), ErrDatabaseManagementUnsupported, "Dropping a database requires a client")
+	assert.ErrorIs(t, m.UnloadDatabase("tenant"), ErrDatabaseManagementUnsupported, "Unloading a database requires a client")
+}
@@ -0,0 +1,274 @@
+package immudbGorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// decimalSerializerName is the name the DecimalSerializer is registered with,
+// e.g. `gorm:"type:decimal(18,4);serializer:immudb_decimal"`.
+const decimalSerializerName = "immudb_decimal"
+
+// maxIntegerPrecision is the largest number of digits of decimals, which are
+// stored in INTEGER columns. Decimals with more digits are stored as BLOB.
+const maxIntegerPrecision = 18
+
+func init() {
+	schema.RegisterSerializer(decimalSerializerName, DecimalSerializer{})
+}
+
+// decimalTypeRegexp matches the types of decimals, e.g. decimal(18,4) or
+// numeric(10).
+var decimalTypeRegexp = regexp.MustCompile(`(?i)^\s*(?:decimal|numeric)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)\s*$`)
+
+// ErrDecimalWithoutSerializer is returned by the migrator for fields with a
+// decimal type, which are not stored by the DecimalSerializer. immudb has no
+// decimal type, so a column of this type cannot be created.
+type ErrDecimalWithoutSerializer struct {
+	Field string
+	Type  string
+}
+
+func (err *ErrDecimalWithoutSerializer) Error() string {
+	return "the type " + err.Type + " of the field " + err.Field + " is not supported by immudb, add serializer:" + decimalSerializerName + " to store it as a decimal"
+}
+
+// decimalType is the precision and scale of a decimal.
+type decimalType struct {
+	// precision is the total number of digits.
+	precision int
+	// scale is the number of digits after the decimal point.
+	scale int
+}
+
+// decimalTypeOf returns the precision and scale of a field stored by the
+// DecimalSerializer.
+func decimalTypeOf(field *schema.Field) (decimalType, error) {
+	match := decimalTypeRegexp.FindStringSubmatch(field.TagSettings["TYPE"])
+	if match == nil {
+		return decimalType{}, fmt.Errorf("the field %s needs a type like decimal(18,4) to be stored as a decimal", field.Name)
+	}
+	t := decimalType{}
+	t.precision, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		t.scale, _ = strconv.Atoi(match[2])
+	}
+	if t.precision < 1 || t.scale > t.precision {
+		return decimalType{}, fmt.Errorf("the type %s of the field %s is not a valid decimal type", field.TagSettings["TYPE"], field.Name)
+	}
+	return t, nil
+}
+
+// isDecimalField returns true if the values of a field are stored by the
+// DecimalSerializer.
+func isDecimalField(field *schema.Field) bool {
+	switch field.Serializer.(type) {
+	case DecimalSerializer, *DecimalSerializer:
+		return true
+	}
+	return false
+}
+
+// checkDecimalField returns an error if a field has a decimal type, but is
+// not stored by the DecimalSerializer.
+func checkDecimalField(field *schema.Field) error {
+	t := strings.ToLower(strings.TrimSpace(field.TagSettings["TYPE"]))
+	if (strings.HasPrefix(t, "decimal") || strings.HasPrefix(t, "numeric")) && !isDecimalField(field) {
+		return &ErrDecimalWithoutSerializer{Field: field.Name, Type: field.TagSettings["TYPE"]}
+	}
+	return nil
+}
+
+// blobSize returns the number of bytes of the BLOB decimals with more digits
+// than an INTEGER can hold are stored in. It can hold all values with the
+// precision of the type and a sign bit.
+func (t decimalType) blobSize() int {
+	bits := int(math.Ceil(float64(t.precision)*math.Log2(10))) + 1
+	return (bits + 7) / 8
+}
+
+// dataType returns the type of the column decimals are stored in.
+func (t decimalType) dataType() string {
+	if t.precision <= maxIntegerPrecision {
+		return "INTEGER"
+	}
+	return fmt.Sprintf("BLOB[%d]", t.blobSize())
+}
+
+// DecimalSerializer stores decimals losslessly as integers, which contain the
+// value multiplied by 10 to the power of the scale of the decimal. Decimals
+// with up to 18 digits are stored in INTEGER columns, decimals with more
+// digits in BLOB columns as big-endian two's complement with an inverted sign
+// bit. Both keep the order of the values when the column is sorted or
+// indexed.
+//
+// The precision and scale are taken from the type of the field, e.g.
+// `gorm:"type:decimal(18,4);serializer:immudb_decimal"`. Fields can be of the
+// types big.Rat, string, float64 or any type implementing driver.Valuer and
+// sql.Scanner with decimal strings, like decimal.Decimal of
+// github.com/shopspring/decimal.
+type DecimalSerializer struct{}
+
+// Value encodes the value of a field.
+func (DecimalSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	t, err := decimalTypeOf(field)
+	if err != nil {
+		return nil, err
+	}
+	rat, err := ratOf(fieldValue)
+	if err != nil || rat == nil {
+		return nil, err
+	}
+
+	// Scale the decimal to an integer without losing digits.
+	unscaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(pow10(t.scale)))
+	if !unscaled.IsInt() {
+		return nil, fmt.Errorf("the value %s of %s has more than %d decimal places", rat.RatString(), field.Name, t.scale)
+	}
+	n := unscaled.Num()
+	if new(big.Int).Abs(n).Cmp(pow10(t.precision)) >= 0 {
+		return nil, fmt.Errorf("the value %s of %s has more than %d digits", rat.FloatString(t.scale), field.Name, t.precision)
+	}
+
+	if t.precision <= maxIntegerPrecision {
+		return n.Int64(), nil
+	}
+	size := t.blobSize()
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(8*size)))
+	}
+	encoded := n.FillBytes(make([]byte, size))
+	encoded[0] ^= 0x80
+	return encoded, nil
+}
+
+// Scan decodes a value of the database into a field.
+func (DecimalSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	t, err := decimalTypeOf(field)
+	if err != nil {
+		return err
+	}
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		var n *big.Int
+		switch v := dbValue.(type) {
+		case int64:
+			n = big.NewInt(v)
+		case []byte:
+			if len(v) != t.blobSize() {
+				return fmt.Errorf("the value of %s has %d bytes instead of the %d bytes of a decimal", field.Name, len(v), t.blobSize())
+			}
+			encoded := append([]byte{}, v...)
+			encoded[0] ^= 0x80
+			n = new(big.Int).SetBytes(encoded)
+			if encoded[0]&0x80 != 0 {
+				n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(encoded))))
+			}
+		default:
+			return fmt.Errorf("the value of %s of type %T cannot be read as a decimal", field.Name, dbValue)
+		}
+		rat := new(big.Rat).SetFrac(n, pow10(t.scale))
+		if err := setDecimal(fieldValue.Elem(), rat, t.scale); err != nil {
+			return fmt.Errorf("the field %s: %w", field.Name, err)
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// ratOf converts the value of a decimal field to a rational number. nil is
+// returned for NULL values.
+func ratOf(v interface{}) (*big.Rat, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case *big.Rat:
+		return v, nil
+	case big.Rat:
+		return &v, nil
+	case string:
+		rat, ok := new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a decimal", v)
+		}
+		return rat, nil
+	case []byte:
+		return ratOf(string(v))
+	case float64:
+		return ratOf(strconv.FormatFloat(v, 'f', -1, 64))
+	case float32:
+		return ratOf(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case int64:
+		return new(big.Rat).SetInt64(v), nil
+	case driver.Valuer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		value, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		return ratOf(value)
+	case fmt.Stringer:
+		return ratOf(v.String())
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return ratOf(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return ratOf(rv.Float())
+	case reflect.String:
+		return ratOf(rv.String())
+	}
+	return nil, fmt.Errorf("a value of type %T cannot be stored as a decimal", v)
+}
+
+// setDecimal assigns a rational number to the value of a decimal field.
+// Strings are formatted with the number of decimal places of the scale.
+func setDecimal(target reflect.Value, rat *big.Rat, scale int) error {
+	if target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+	switch v := target.Addr().Interface().(type) {
+	case *big.Rat:
+		v.Set(rat)
+		return nil
+	case sql.Scanner:
+		return v.Scan(rat.FloatString(scale))
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(rat.FloatString(scale))
+	case reflect.Float32, reflect.Float64:
+		f, _ := rat.Float64()
+		target.SetFloat(f)
+	default:
+		return fmt.Errorf("a decimal cannot be read into a value of type %s", target.Type())
+	}
+	return nil
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,133 @@
+package immudbGorm
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrUnsupportedDefault is returned if the default value of a field is a
+// function, which cannot be evaluated by the dialector.
+type ErrUnsupportedDefault struct {
+	Field   string
+	Default string
+}
+
+func (err *ErrUnsupportedDefault) Error() string {
+	return "the default value " + err.Default + " of the field " + err.Field + " is not supported, only constants, now() and random uuids can be used"
+}
+
+// defaultFunctions are the functions, which can be used as default values.
+// They are evaluated for each created row. The names are in lower case.
+var defaultFunctions = map[string]func(field *schema.Field) interface{}{
+	"now()":               defaultNow,
+	"now":                 defaultNow,
+	"current_timestamp()": defaultNow,
+	"current_timestamp":   defaultNow,
+	"gen_random_uuid()":   defaultRandomUUID,
+	"uuid_generate_v4()":  defaultRandomUUID,
+	"random_uuid()":       defaultRandomUUID,
+	"uuid()":              defaultRandomUUID,
+}
+
+// defaultNow returns the current time.
+func defaultNow(*schema.Field) interface{} {
+	return time.Now()
+}
+
+// defaultRandomUUID returns a random uuid. Fields of string types get the
+// string representation of the uuid.
+func defaultRandomUUID(field *schema.Field) interface{} {
+	id := uuid.New()
+	if field.IndirectFieldType.Kind() == reflect.String {
+		return id.String()
+	}
+	return id
+}
+
+// applyDefaultValues sets the fields of created models with a default value
+// to it, if they are not set. immudb does not support default values of
+// columns, so they are applied before the INSERT statement is built.
+//
+// gorm applies most constant default values itself. Defaults which are
+// functions, like now() or gen_random_uuid(), are evaluated here, as well as
+// constants gorm could not parse for the type of a field, e.g. a uuid.
+func applyDefaultValues(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() != 0 {
+		return
+	}
+
+	// Only fields with a default value gorm does not apply itself are
+	// handled, auto incremented ones are set by immudb.
+	selectColumns, restricted := stmt.SelectAndOmitColumns(true, false)
+	fields := []*schema.Field{}
+	for _, field := range stmt.Schema.Fields {
+		if !needsDefaultValue(field) {
+			continue
+		}
+		if v, ok := selectColumns[field.DBName]; (ok && v) || (!ok && !restricted) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	apply := func(rv reflect.Value) {
+		for _, field := range fields {
+			if _, isZero := field.ValueOf(stmt.Context, rv); !isZero {
+				continue
+			}
+			value, err := defaultValueOf(field)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			db.AddError(field.Set(stmt.Context, rv, value))
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if rv := reflect.Indirect(stmt.ReflectValue.Index(i)); rv.IsValid() {
+				apply(rv)
+			}
+		}
+	case reflect.Struct:
+		apply(stmt.ReflectValue)
+	}
+}
+
+// needsDefaultValue returns true if the default value of a field has to be
+// applied by the dialector. gorm inserts constants it parsed itself, unless
+// their type does not match the field, e.g. the string of a uuid.
+func needsDefaultValue(field *schema.Field) bool {
+	if !field.HasDefaultValue || field.AutoIncrement || field.DBName == "" || field.DataType == "" {
+		return false
+	}
+	if field.DefaultValue == "" || strings.EqualFold(field.DefaultValue, "null") {
+		return false
+	}
+	if field.DefaultValueInterface == nil {
+		return true
+	}
+	return !reflect.TypeOf(field.DefaultValueInterface).ConvertibleTo(field.IndirectFieldType)
+}
+
+// defaultValueOf evaluates the default value of a field.
+func defaultValueOf(field *schema.Field) (interface{}, error) {
+	if function, ok := defaultFunctions[strings.ToLower(strings.TrimSpace(field.DefaultValue))]; ok {
+		return function(field), nil
+	}
+	if strings.Contains(field.DefaultValue, "(") {
+		return nil, &ErrUnsupportedDefault{Field: field.Name, Default: field.DefaultValue}
+	}
+	// Constants are set as strings, which are converted to the type of the
+	// field when it is set.
+	return strings.Trim(field.DefaultValue, `'"`), nil
+}
@@ -1,31 +1,84 @@
 package immudbGorm
 
 import (
+	"crypto/ecdsa"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/codenotary/immudb/pkg/client"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 )
 
 type Config struct {
 	DriverName string
-	DSN        string
+	// DSN is the connection string of the database. For the immudb driver it
+	// is checked with ParseDSN when the database is opened and can be created
+	// from a DSN with its String method.
+	DSN string
+	// DSNConfig describes the database with a typed DSN. If it is set, it is
+	// used instead of DSN.
+	DSNConfig *DSN
+	// Conn is an existing connection pool, which is used instead of opening
+	// a new one for the DSN. The ids of committed transactions are only
+	// captured if the driver connections of the pool report them directly.
+	Conn gorm.ConnPool
+	// Client is an immudb client with an open session for the database. It
+	// is used for the features immudb SQL does not provide, like verified
+	// reads, proofs, snapshots and dropping databases. If it is not set, the
+	// dialector opens a session with the credentials of the DSN when one of
+	// these features is used first, and closes it with the connection pool.
+	// Embedded databases and pools passed in Conn have no client then, so
+	// these features are not available for them.
+	Client client.ImmuClient
+	// VerifyReads enables the verification of all rows returned by queries
+	// against the cryptographic proofs of immudb. Verification can also be
+	// enabled for single queries with the Verified scope. The ids of the
+	// tables and columns read with verification are pinned on first use.
+	VerifyReads bool
+	// StateStore persists the database state trusted by verified reads.
+	// Every verification checks that the database is still consistent with
+	// the last trusted state. Without a store, the state is only trusted as
+	// long as the application is running.
+	StateStore StateStore
+	// ServerPublicKey is the public key the immudb server signs the states
+	// of its databases with. If it is set, verified reads only trust states
+	// signed by the server and fail with ErrInvalidStateSignature otherwise.
+	// Without a key, the first state of a database is trusted on first use.
+	ServerPublicKey *ecdsa.PublicKey
+	// DisableQuoting writes identifiers without quotes. This is required for
+	// older immudb servers, which do not accept quoted identifiers. Columns
+	// named like reserved words, e.g. table or unique, cannot be used then.
+	DisableQuoting bool
+	// DisableJSON creates the columns of JSON fields as VARCHAR instead of
+	// JSON. This is required for immudb servers before version 1.9, which do
+	// not support the JSON type.
+	DisableJSON bool
 }
 
 type dialector struct {
 	*Config
+	// trust is the database state trusted by verified reads.
+	trust *trustedState
+	// session provides the immudb client of the database. It is nil if no
+	// client is available.
+	session *clientSession
+	// databases contains the dialectors of all databases used by sessions
+	// created with UseDatabase.
+	databases *databaseCache
 }
 
 // Open creates a new dialector for connecting to the database at dsn.
 func Open(dsn string) gorm.Dialector {
-	return &dialector{Config: &Config{DSN: dsn, DriverName: "immudb"}}
+	return newDialector(&Config{DSN: dsn, DriverName: "immudb"})
 }
 
 // New creates a new dialector using the given configuration.
@@ -34,7 +87,21 @@ func New(config Config) gorm.Dialector {
 	if config.DriverName == "" {
 		config.DriverName = "immudb"
 	}
-	return &dialector{Config: &config}
+	if config.DSNConfig != nil {
+		config.DSN = config.DSNConfig.String()
+	}
+	return newDialector(&config)
+}
+
+// newDialector creates a dialector for the given configuration.
+func newDialector(config *Config) *dialector {
+	d := &dialector{
+		Config:  config,
+		session: newClientSession(config),
+		trust:   newTrustedState(config.StateStore, config.DSN, config.ServerPublicKey),
+	}
+	d.databases = &databaseCache{root: d}
+	return d
 }
 
 // -- Dialector interface --
@@ -46,21 +113,90 @@ func (dialector dialector) Name() string {
 
 // Initialize sets up the dialector for a database.
 func (dialector dialector) Initialize(db *gorm.DB) (err error) {
-	db.ConnPool, err = sql.Open(dialector.DriverName, dialector.DSN)
-	if err != nil {
-		return err
+	verifyReads := dialector.VerifyReads
+	if dialector.Conn != nil {
+		// Use the connection pool provided by the application.
+		db.ConnPool = dialector.Conn
+	} else {
+		// Check the connection string of the immudb driver before connecting,
+		// so that a misconfiguration is reported right away. Other drivers
+		// may use a different format.
+		dsn := dialector.DSN
+		if dialector.DriverName == "immudb" {
+			parsed, err := ParseDSN(dsn)
+			if err != nil {
+				return err
+			}
+			dsn = parsed.driverString()
+			verifyReads = verifyReads || parsed.VerifyReads
+		}
+		// Open the database only to retrieve the driver. The connections of the
+		// driver are wrapped to record the ids of committed immudb transactions.
+		pool, err := sql.Open(dialector.DriverName, dsn)
+		if err != nil {
+			return err
+		}
+		connector, err := newTxIDConnector(pool.Driver(), dsn, dialector.session)
+		pool.Close()
+		if err != nil {
+			return err
+		}
+		db.ConnPool = sql.OpenDB(connector)
 	}
+	// Remember the connection pool, so that sessions switching back to this
+	// database with UseDatabase can use it.
+	dialector.databases.rootPool = db.ConnPool
 	// Register default callbacks for insert and delete.
 	// The default update callback is not useable,
-	// as immudb uses the upsert clause instead of update.
+	// as it builds statements immudb cannot execute.
+	// OFFSET is part of the LIMIT clause and HAVING part of the GROUP BY
+	// clause in gorm. Both are written in the syntax immudb expects.
 	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{
 		LastInsertIDReversed: true,
-		CreateClauses:        []string{"INSERT", "VALUES"},
-		UpdateClauses:        []string{"UPDATE", "SET", "WHERE", "ORDER BY", "LIMIT"},
+		CreateClauses:        []string{"INSERT", "VALUES", "ON CONFLICT"},
+		UpdateClauses:        updateClauses,
 		DeleteClauses:        []string{"DELETE", "FROM", "WHERE", "ORDER BY", "LIMIT"},
 		QueryClauses:         []string{"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT"},
 	})
-	return nil
+	// Replace the default update callback by one built for immudb.
+	if err := db.Callback().Update().Replace("gorm:update", update); err != nil {
+		return err
+	}
+	// Register custom INSERT and ON CONFLICT clause builders, which
+	// translate the handling of conflicts into immudb SQL.
+	db.ClauseBuilders["INSERT"] = buildInsert
+	db.ClauseBuilders["ON CONFLICT"] = buildOnConflict
+	// Register a custom FROM clause builder, which adds the time travel
+	// period of a query to every table.
+	db.ClauseBuilders["FROM"] = buildFrom
+	// Register a custom SELECT clause builder, which reads revision fields
+	// of models from the _rev pseudo column.
+	db.ClauseBuilders["SELECT"] = buildSelect
+	// Register callbacks for handling revision fields on writes.
+	if err := registerRevCallbacks(db); err != nil {
+		return err
+	}
+	// Register a callback applying the default values of fields, which
+	// immudb does not support for columns.
+	if err := db.Callback().Create().Before("gorm:create").Register("immudb:default_values", applyDefaultValues); err != nil {
+		return err
+	}
+	// Register callbacks for capturing the transaction ids of writes.
+	if err := registerTxIDCallbacks(db); err != nil {
+		return err
+	}
+	// Replace the default query callback by one assigning the columns of
+	// joined associations.
+	if err := db.Callback().Query().Replace("gorm:query", query); err != nil {
+		return err
+	}
+	// Register a callback emulating COUNT(DISTINCT column), which immudb
+	// does not support.
+	if err := db.Callback().Query().Before("gorm:query").Register("immudb:count_distinct", countDistinct); err != nil {
+		return err
+	}
+	// Register a callback for verifying the rows returned by queries.
+	return db.Callback().Query().After("gorm:query").Register("immudb:verify", verifyRows(verifyReads))
 
 }
 
@@ -96,6 +232,29 @@ func (dialector dialector) DataTypeOf(field *schema.Field) string {
 		dataType = string(field.DataType)
 	}
 
+	// Fields serialized to JSON are stored in JSON columns, or as text if
+	// the JSON type is not supported by the server.
+	if isJSONField(field) {
+		dataType = "JSON"
+		if dialector.DisableJSON {
+			dataType = "VARCHAR"
+		}
+	}
+
+	// Decimals are stored as scaled integers by the DecimalSerializer. Their
+	// type depends on the precision given by the type of the field.
+	if isDecimalField(field) {
+		if t, err := decimalTypeOf(field); err == nil {
+			return t.dataType()
+		}
+	}
+
+	// Unsigned integers encoded by the Uint64Serializer always have 8 bytes.
+	// The size of the field is the number of bits of the integer.
+	if isUint64Field(field) {
+		return "BLOB[8]"
+	}
+
 	// Add a size constraint for the field if one is set.
 	// Currentlty size constraints are only supported for BLOB and VARCHAR.
 	if (dataType == "BLOB" || dataType == "VARCHAR") && field.Size > 0 {
@@ -111,19 +270,41 @@ func (dialector dialector) DataTypeOf(field *schema.Field) string {
 }
 
 // DefaultValueOf creates an sql expression to set a default value for a column.
-// As immudb does not support default values at the moment,
-// just an empty expression is returned for now.
+// As immudb does not support default values at the moment, they are applied
+// to the fields of created models before the INSERT statement is built.
+// Fields, which are still not set, e.g. with the default NULL, are inserted
+// as NULL.
 func (dialector dialector) DefaultValueOf(*schema.Field) clause.Expression {
-	return clause.Expr{}
+	return clause.Expr{SQL: "NULL"}
 }
 
 // BindVarTo adds a placeholder for a variable in a SQL query.
 func (dialector dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
 	writer.WriteByte('?')
+	// INTEGER columns are signed, larger unsigned integers would overflow.
+	if err := checkUintRange(v); err != nil {
+		stmt.AddError(err)
+	}
+	// immudb only accepts JSON values given as text, raw JSON messages would
+	// be passed as BLOB otherwise.
+	if raw, ok := v.(json.RawMessage); ok && len(stmt.Vars) > 0 {
+		stmt.Vars[len(stmt.Vars)-1] = string(raw)
+	}
 	// Add string to UUID conversion, as a workaround for a bug in immudb.
 	// ImmuDB expects raw UUIDs to be set as parameters, but does not have a
 	// method to transfer raw UUID values. Only string encoded uuids can be
 	// transferred, but these are not parsed to uuids currently.
+	isUUID := isUUIDValue(v)
+	// Append an explicit cast to a uuid, if the value was determined to be a
+	// a uuid value.
+	if isUUID {
+		writer.WriteString("::UUID")
+	}
+}
+
+// isUUIDValue determines if v is a uuid, which has to be cast explicitly to
+// be stored as a UUID by immudb.
+func isUUIDValue(v interface{}) bool {
 	_, isUUID := v.(uuid.UUID)
 	// If the type is not directly a uuid, check if it implements the valuer
 	// interface and serialized itself to a uuid string.
@@ -151,21 +332,51 @@ func (dialector dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement,
 	// 		}
 	// 	}
 	// }
-	// Append an explicit cast to a uuid, if the value was determined to be a
-	// a uuid value.
-	if isUUID {
-		writer.WriteString("::UUID")
-	}
+	return isUUID
 }
 
-// QuoteTo quotes an identifier in a SQL query.
-// As immudb does not support quoting identifiers at the moment,
-// the dialector does not perform any quoting so far.
+// ErrInvalidIdentifier is returned for identifiers containing double quotes.
+// immudb reads quoted identifiers like unquoted ones, they can only consist of
+// letters, digits and underscores, and quotes cannot be escaped within them.
+var ErrInvalidIdentifier = errors.New("identifiers cannot contain double quotes in immudb")
+
+// QuoteTo quotes an identifier in a SQL query with double quotes.
+// Qualified identifiers like table.column are quoted for every part.
+// Identifiers, which are quoted already, are not quoted again. Identifiers
+// containing double quotes are rejected with ErrInvalidIdentifier.
+//
+// immudb converts all identifiers to lower case, quoted ones as well. Quoting
+// only allows to use reserved words as identifiers. Identifiers are written
+// in lower case, so that the SQL matches the names immudb uses, e.g. the alias
+// of a joined association like Company in a condition "Company.name = ?".
 func (dialector dialector) QuoteTo(writer clause.Writer, str string) {
-	writer.WriteString(str)
+	if dialector.DisableQuoting {
+		writer.WriteString(str)
+		return
+	}
+	for i, part := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		if len(part) >= 2 && part[0] == '"' && part[len(part)-1] == '"' {
+			part = part[1 : len(part)-1]
+		}
+		// The error can only be reported for statements, other queries
+		// are rejected by the server.
+		if strings.Contains(part, `"`) {
+			if stmt, ok := writer.(*gorm.Statement); ok {
+				stmt.AddError(fmt.Errorf("%w: %s", ErrInvalidIdentifier, str))
+			}
+		}
+		writer.WriteByte('"')
+		writer.WriteString(strings.ToLower(part))
+		writer.WriteByte('"')
+	}
 }
 
-// Explain creates a string describing the SQL query.
+// Explain creates a string describing the SQL query. The variables are
+// written as immudb literals, so that the query can be executed as it is,
+// e.g. in the immudb console.
 func (dialector dialector) Explain(sql string, vars ...interface{}) string {
-	return logger.ExplainSQL(sql, nil, `'`, vars...)
+	return explainSQL(sql, vars...)
 }
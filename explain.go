@@ -0,0 +1,127 @@
+package immudbGorm
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLiteralFormat is the format of timestamps in literals, which is
+// parsed by immudb when casting a VARCHAR to a TIMESTAMP. immudb stores
+// timestamps without a time zone with a precision of microseconds.
+const timestampLiteralFormat = "2006-01-02 15:04:05.999999"
+
+// explainSQL replaces the placeholders of a SQL query with the immudb literals
+// of its variables. Question marks within string literals and quoted
+// identifiers are not placeholders and are kept as they are.
+func explainSQL(sql string, vars ...interface{}) string {
+	var builder strings.Builder
+	builder.Grow(len(sql) + 16*len(vars))
+	next := 0
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			// An escaped quote ends the quoted text and starts it again
+			// immediately, which does not change the result.
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && next < len(vars):
+			v := vars[next]
+			next++
+			builder.WriteString(literalOf(v))
+			// BindVarTo casts uuids explicitly, which has to be done as well for
+			// queries not built by gorm.
+			if isUUIDValue(v) && !strings.HasPrefix(sql[i+1:], "::UUID") {
+				builder.WriteString("::UUID")
+			}
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// literalOf returns the immudb literal of a value.
+func literalOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return quoteLiteral(v)
+	case []byte:
+		if v == nil {
+			return "NULL"
+		}
+		return "x'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "CAST(" + quoteLiteral(v.UTC().Format(timestampLiteralFormat)) + " AS TIMESTAMP)"
+	case driver.Valuer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "NULL"
+		}
+		value, err := v.Value()
+		if err != nil {
+			return quoteLiteral(fmt.Sprint(v))
+		}
+		return literalOf(value)
+	}
+
+	// Other types are written according to their kind, e.g. named integer
+	// types and pointers.
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL"
+		}
+		return literalOf(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return floatLiteral(rv.Float(), rv.Type().Bits())
+	case reflect.Bool:
+		return literalOf(rv.Bool())
+	case reflect.String:
+		return quoteLiteral(rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return literalOf(rv.Bytes())
+		}
+	}
+	if t, ok := v.(fmt.Stringer); ok {
+		return quoteLiteral(t.String())
+	}
+	return quoteLiteral(fmt.Sprint(v))
+}
+
+// floatLiteral returns the literal of a float. It always contains a decimal
+// point, as immudb would parse it as an integer otherwise.
+func floatLiteral(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'f', -1, bits)
+	if !strings.ContainsAny(s, ".NI") {
+		s += ".0"
+	}
+	return s
+}
+
+// quoteLiteral quotes a string literal. Quotes within the string are escaped
+// by doubling them.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,183 @@
+package immudbGorm
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Revision is one stored version of a row.
+type Revision struct {
+	// Rev is the revision number immudb assigned to this version of the row.
+	// The first version of a row has the revision 1.
+	Rev uint64
+	// TxID is the id of the immudb transaction, which wrote this version of
+	// the row. It can be used to time travel to the state after the write,
+	// see AsOfTx.
+	TxID uint64
+	// Value is a pointer to a new instance of the model, containing the
+	// values of the row in this revision.
+	Value interface{}
+}
+
+// History returns every stored revision of the row of a model, identified by
+// the values of its primary key. For composite primary keys the values have
+// to be given in the order of the primary fields of the model. The table of
+// the model is used, unless another one is set with db.Table.
+//
+// The revisions are returned in ascending order. immudb does not expose the
+// transaction of a revision in its SQL interface, only the revision number.
+// The transactions are therefore searched by reading the row during periods
+// of transactions, which requires a number of queries per revision that is
+// logarithmic in the number of transactions committed between it and the
+// previous revision. Reads during a period skip deleted rows, if the row has
+// been deleted and inserted again, the transactions of its revisions cannot
+// be determined and an error is returned.
+func History(db *gorm.DB, model interface{}, pk ...interface{}) ([]Revision, error) {
+	stmt := &gorm.Statement{DB: db, Context: db.Statement.Context}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	if db.Statement.Table != "" {
+		stmt.Table = db.Statement.Table
+	}
+	schema := stmt.Schema
+	if len(schema.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("the history of table %s cannot be retrieved as it does not have a primary key", stmt.Table)
+	}
+	if len(pk) != len(schema.PrimaryFields) {
+		return nil, fmt.Errorf("the history of table %s requires %d primary key values, but %d were given", stmt.Table, len(schema.PrimaryFields), len(pk))
+	}
+
+	// Select the revision and all columns of the model.
+	columns := []clause.Expression{clause.Expr{SQL: "_rev"}}
+	for _, name := range schema.DBNames {
+		columns = append(columns, clause.Expr{SQL: "?", Vars: []interface{}{clause.Column{Name: name}}})
+	}
+	// Restrict the history to the requested row.
+	conditions := make([]clause.Expression, len(pk))
+	for i, field := range schema.PrimaryFields {
+		conditions[i] = clause.Eq{Column: clause.Column{Name: field.DBName}, Value: pk[i]}
+	}
+
+	rows, err := db.Session(&gorm.Session{NewDB: true}).Raw(
+		"SELECT ? FROM (HISTORY OF ?) WHERE ? ORDER BY _rev",
+		clause.CommaExpression{Exprs: columns}, clause.Table{Name: stmt.Table}, clause.And(conditions...),
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	values := make([]interface{}, len(schema.DBNames)+1)
+	for rows.Next() {
+		var rev uint64
+		values[0] = &rev
+		for i, name := range schema.DBNames {
+			values[i+1] = schema.FieldsByDBName[name].NewValuePool.Get()
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		// Decode the row into a new instance of the model.
+		value := reflect.New(schema.ModelType)
+		for i, name := range schema.DBNames {
+			field := schema.FieldsByDBName[name]
+			if err := field.Set(stmt.Context, value.Elem(), values[i+1]); err != nil {
+				return nil, err
+			}
+			field.NewValuePool.Put(values[i+1])
+		}
+		revisions = append(revisions, Revision{Rev: rev, Value: value.Interface()})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every revision has been written after the one before it.
+	table := clause.Table{Name: stmt.Table}
+	column := clause.Column{Name: schema.PrimaryFields[0].DBName}
+	since := uint64(1)
+	for i := range revisions {
+		tx, err := revisionTx(db, table, column, clause.And(conditions...), since)
+		if err != nil {
+			return nil, err
+		}
+		if tx == 0 {
+			return nil, fmt.Errorf("the transaction of revision %d of a row of table %s cannot be determined", revisions[i].Rev, stmt.Table)
+		}
+		revisions[i].TxID = tx
+		since = tx + 1
+	}
+	return revisions, nil
+}
+
+// revisionTx returns the id of the first transaction since the transaction
+// since, which wrote the row matching the conditions. A row read during a
+// period is only returned if it has been written during the period. Hence
+// the period starting at since is doubled until it contains a write of the
+// row and bisected afterwards. If the row has not been written since the
+// transaction since, 0 is returned.
+func revisionTx(db *gorm.DB, table clause.Table, column clause.Column, conditions clause.Expression, since uint64) (uint64, error) {
+	written := func(until uint64) (bool, error) {
+		period := Period{Start: &PeriodInstant{Tx: since}}
+		if until != 0 {
+			period.End = &PeriodInstant{Tx: until}
+		}
+		rows, err := db.Session(&gorm.Session{NewDB: true}).Raw(
+			"SELECT ? FROM ? WHERE ? LIMIT 1", column, tableDuring{Table: table, Period: period}, conditions,
+		).Rows()
+		if err != nil {
+			return false, err
+		}
+		defer rows.Close()
+		found := rows.Next()
+		return found, rows.Err()
+	}
+
+	// The row has to be written since the transaction at all.
+	if ok, err := written(0); err != nil || !ok {
+		return 0, err
+	}
+	// The write is not in the period up to before, but in the one up to until.
+	before, until := since-1, since
+	for step := uint64(1); ; step *= 2 {
+		ok, err := written(until)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			break
+		}
+		before, until = until, until+step
+	}
+	for until-before > 1 {
+		middle := before + (until-before)/2
+		ok, err := written(middle)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			until = middle
+		} else {
+			before = middle
+		}
+	}
+	return until, nil
+}
+
+// tableDuring is a reference to a table read during a period.
+type tableDuring struct {
+	Table  clause.Table
+	Period Period
+}
+
+// Build writes the table followed by the period, e.g. users SINCE TX 1.
+func (table tableDuring) Build(builder clause.Builder) {
+	builder.WriteQuoted(table.Table)
+	builder.WriteByte(' ')
+	table.Period.Build(builder)
+}
@@ -0,0 +1,143 @@
+package immudbGorm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils"
+)
+
+// rawMessageType is the type of json.RawMessage fields.
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// isJSONField returns true if the values of a field are stored as JSON. This
+// is the case for fields with the json serializer and json.RawMessage fields,
+// unless another type is set explicitly, and for fields with the json data
+// type like datatypes.JSON. gorm derives the data type of serialized fields
+// from their kind, so scalar fields with the json serializer are recognized
+// by the serializer alone.
+func isJSONField(field *schema.Field) bool {
+	if strings.EqualFold(string(field.DataType), "json") {
+		return true
+	}
+	if _, ok := field.TagSettings["TYPE"]; ok {
+		return false
+	}
+	if field.IndirectFieldType == rawMessageType {
+		return true
+	}
+	switch field.Serializer.(type) {
+	case schema.JSONSerializer, *schema.JSONSerializer:
+		return true
+	}
+	return false
+}
+
+// jsonColumnsOf returns for each column read by a query, whether it is a
+// field stored as JSON. Columns of joined associations are resolved in the
+// schemas of the associations. The second result is false if none of the
+// columns is stored as JSON.
+func jsonColumnsOf(s *schema.Schema, columns []string) ([]bool, bool) {
+	isJSON := make([]bool, len(columns))
+	found := false
+	for idx, column := range columns {
+		names := utils.SplitNestedRelationName(column)
+		fieldSchema := s
+		for _, name := range names[:len(names)-1] {
+			relation := relationFold(fieldSchema, name)
+			if relation == nil {
+				fieldSchema = nil
+				break
+			}
+			fieldSchema = relation.FieldSchema
+		}
+		if fieldSchema == nil {
+			continue
+		}
+		if field := fieldSchema.LookUpField(names[len(names)-1]); field != nil && isJSONField(field) {
+			isJSON[idx] = true
+			found = true
+		}
+	}
+	return isJSON, found
+}
+
+// jsonRows are the rows of a query reading fields stored as JSON. immudb
+// returns the values of JSON columns decoded, e.g. objects as maps, strings
+// without quotes and numbers as float64. They are encoded again before they
+// are passed to the scanners of the fields, which expect the JSON text like
+// it is returned for VARCHAR columns.
+type jsonRows struct {
+	gorm.Rows
+	// isJSON contains for each column, whether it is stored as JSON.
+	isJSON []bool
+}
+
+// Scan copies the columns of the current row into dest.
+func (rows jsonRows) Scan(dest ...interface{}) error {
+	// gorm reads the values from the slice passed to Scan after scanning, so
+	// the scanners must not replace its elements.
+	wrapped := make([]interface{}, len(dest))
+	for idx, d := range dest {
+		wrapped[idx] = d
+		if idx >= len(rows.isJSON) || !rows.isJSON[idx] {
+			continue
+		}
+		if scanner, ok := d.(sql.Scanner); ok {
+			wrapped[idx] = jsonScanner{scanner}
+		} else {
+			wrapped[idx] = jsonScanner{jsonTarget{d}}
+		}
+	}
+	return rows.Rows.Scan(wrapped...)
+}
+
+// jsonScanner encodes decoded JSON values before they are scanned.
+type jsonScanner struct {
+	sql.Scanner
+}
+
+// Scan assigns a value of the database.
+func (s jsonScanner) Scan(value interface{}) error {
+	if value != nil {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		value = encoded
+	}
+	return s.Scanner.Scan(value)
+}
+
+// jsonTarget assigns JSON text to a destination, which is not a scanner,
+// e.g. the json.RawMessage fields scanned by gorm.
+type jsonTarget struct {
+	dest interface{}
+}
+
+// Scan assigns the JSON text value to the destination.
+func (t jsonTarget) Scan(value interface{}) error {
+	text, _ := value.([]byte)
+	switch d := t.dest.(type) {
+	case *json.RawMessage:
+		*d = append(json.RawMessage(nil), text...)
+	case *[]byte:
+		*d = append([]byte(nil), text...)
+	case *string:
+		*d = string(text)
+	case *interface{}:
+		if value == nil {
+			*d = nil
+		} else {
+			*d = append([]byte(nil), text...)
+		}
+	default:
+		return fmt.Errorf("cannot scan a JSON value into %T", t.dest)
+	}
+	return nil
+}
@@ -38,11 +38,20 @@ func (m Migrator) AddColumn(value interface{}, name string) error {
 			return fmt.Errorf("failed to look up field with name: %s", name)
 		}
 
-		if !f.IgnoreMigration {
-			return m.DB.Exec(
+		// The revision of a row is stored in the _rev pseudo column,
+		// which must not be created as a real column.
+		if !f.IgnoreMigration && f != revFieldOf(stmt.Schema) {
+			if err := checkDecimalField(f); err != nil {
+				return err
+			}
+			err := m.DB.Exec(
 				"ALTER TABLE ? ADD COLUMN ? ?",
 				m.CurrentTable(stmt), clause.Column{Name: f.DBName}, m.DB.Migrator().FullDataTypeOf(f),
 			).Error
+			if err != nil || !needsUniqueIndex(stmt.Schema, f) {
+				return err
+			}
+			return m.createUniqueIndex(stmt, f)
 		}
 
 		return nil
@@ -66,11 +75,11 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 			values := []interface{}{m.CurrentTable(stmt), opts}
 
 			createIndexSQL := "CREATE "
-			// Classes are currently not suppored.
-			//
-			// if idx.Class != "" {
-			// 	createIndexSQL += idx.Class + " "
-			// }
+			// Unique indexes are created for uniqueIndex and index:,unique tags.
+			// Other classes are currently not suppored.
+			if idx.Class == "UNIQUE" {
+				createIndexSQL += "UNIQUE "
+			}
 			createIndexSQL += "INDEX ON ??"
 
 			// Types are currently not suppored.
@@ -96,6 +105,119 @@ func (m Migrator) CreateIndex(value interface{}, name string) error {
 	})
 }
 
+// CreateTable creates the tables of models. This function is a copy of the
+// default create table function with the following differences. Revision
+// fields are not created as columns, even if they are not tagged with
+// -:migration. Fields with a decimal type have to use the DecimalSerializer.
+// Unique indexes are created for fields with the unique tag, as immudb does
+// not support UNIQUE constraints of columns.
+func (m Migrator) CreateTable(values ...interface{}) error {
+	for _, value := range m.ReorderModels(values, false) {
+		tx := m.DB.Session(&gorm.Session{})
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) (err error) {
+			var (
+				createTableSQL = "CREATE TABLE ? ("
+				values         = []interface{}{m.CurrentTable(stmt)}
+				uniqueFields   []*schema.Field
+			)
+
+			// The revision of a row is stored in the _rev pseudo column,
+			// which must not be created as a real column.
+			revField := revFieldOf(stmt.Schema)
+			for _, dbName := range stmt.Schema.DBNames {
+				field := stmt.Schema.FieldsByDBName[dbName]
+				if field.IgnoreMigration || field == revField {
+					continue
+				}
+				if err := checkDecimalField(field); err != nil {
+					return err
+				}
+				createTableSQL += "? ?,"
+				values = append(values, clause.Column{Name: dbName}, m.DB.Migrator().FullDataTypeOf(field))
+				if needsUniqueIndex(stmt.Schema, field) {
+					uniqueFields = append(uniqueFields, field)
+				}
+			}
+
+			if len(stmt.Schema.PrimaryFields) > 0 {
+				createTableSQL += "PRIMARY KEY ?,"
+				primaryKeys := make([]interface{}, 0, len(stmt.Schema.PrimaryFields))
+				for _, field := range stmt.Schema.PrimaryFields {
+					primaryKeys = append(primaryKeys, clause.Column{Name: field.DBName})
+				}
+				values = append(values, primaryKeys)
+			}
+
+			// Indexes are created after the table.
+			for _, idx := range stmt.Schema.ParseIndexes() {
+				defer func(value interface{}, name string) {
+					if err == nil {
+						err = tx.Migrator().CreateIndex(value, name)
+					}
+				}(value, idx.Name)
+			}
+
+			if !m.DB.DisableForeignKeyConstraintWhenMigrating && !m.DB.IgnoreRelationshipsWhenMigrating {
+				for _, rel := range stmt.Schema.Relationships.Relations {
+					if rel.Field.IgnoreMigration {
+						continue
+					}
+					if constraint := rel.ParseConstraint(); constraint != nil && constraint.Schema == stmt.Schema {
+						sql, vars := buildConstraint(constraint)
+						createTableSQL += sql + ","
+						values = append(values, vars...)
+					}
+				}
+			}
+
+			for _, chk := range stmt.Schema.ParseCheckConstraints() {
+				createTableSQL += "CONSTRAINT ? CHECK (?),"
+				values = append(values, clause.Column{Name: chk.Name}, clause.Expr{SQL: chk.Constraint})
+			}
+
+			createTableSQL = strings.TrimSuffix(createTableSQL, ",") + ")"
+			if tableOption, ok := m.DB.Get("gorm:table_options"); ok {
+				createTableSQL += fmt.Sprint(tableOption)
+			}
+			if err = tx.Exec(createTableSQL, values...).Error; err != nil {
+				return err
+			}
+
+			for _, field := range uniqueFields {
+				if err = m.createUniqueIndex(stmt, field); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildConstraint builds the definition of a foreign key constraint. This
+// function is a copy of the unexported one of the default migrator.
+func buildConstraint(constraint *schema.Constraint) (sql string, results []interface{}) {
+	sql = "CONSTRAINT ? FOREIGN KEY ? REFERENCES ??"
+	if constraint.OnDelete != "" {
+		sql += " ON DELETE " + constraint.OnDelete
+	}
+	if constraint.OnUpdate != "" {
+		sql += " ON UPDATE " + constraint.OnUpdate
+	}
+
+	var foreignKeys, references []interface{}
+	for _, field := range constraint.ForeignKeys {
+		foreignKeys = append(foreignKeys, clause.Column{Name: field.DBName})
+	}
+	for _, field := range constraint.References {
+		references = append(references, clause.Column{Name: field.DBName})
+	}
+	results = append(results, clause.Table{Name: constraint.Name}, foreignKeys, clause.Table{Name: constraint.ReferenceSchema.Table}, references)
+	return
+}
+
 // CreateView creates a view.
 //
 // Not implemented as immudb does not support views.
@@ -104,9 +226,19 @@ func (m Migrator) CreateView(name string, option gorm.ViewOption) error {
 }
 
 // CurrentDatabase returns the currently selected database.
+//
+// The name is reported by the immudb server for the session of the client of
+// the database. The name of an embedded database is the last element of its
+// path. If the dialector uses a connection pool provided by the application
+// without a client, the database cannot be determined and an empty name is
+// returned.
 func (m Migrator) CurrentDatabase() (name string) {
-	// TODO add support in the driver to determine the current database.
-	return ""
+	name, err := databaseOf(m.DB)
+	if err != nil {
+		log.Printf("error determining the current database: %v", err)
+		return ""
+	}
+	return name
 }
 
 // DropColumn does not have a custom implementation as the default one is
@@ -164,10 +296,31 @@ func (m Migrator) FullDataTypeOf(field *schema.Field) (expr clause.Expr) {
 
 	// In contrast to the default FullDataTypeOf implementation of the migrator,
 	// this implementation ignores the unique and default setting, as both are
-	// no supported by immudb.
+	// no supported by immudb. Default values are applied to created models by
+	// the dialector instead, unique columns get a unique index.
 	return
 }
 
+// needsUniqueIndex returns true if a field is tagged as unique and its
+// uniqueness is not ensured by another index, i.e. the primary key or a
+// unique index defined by the uniqueIndex tag.
+func needsUniqueIndex(s *schema.Schema, field *schema.Field) bool {
+	if !field.Unique || field.PrimaryKey || field.IgnoreMigration || field == revFieldOf(s) {
+		return false
+	}
+	for _, idx := range s.ParseIndexes() {
+		if idx.Class == "UNIQUE" && len(idx.Fields) == 1 && idx.Fields[0].Field == field {
+			return false
+		}
+	}
+	return true
+}
+
+// createUniqueIndex creates a unique index for a single column.
+func (m Migrator) createUniqueIndex(stmt *gorm.Statement, field *schema.Field) error {
+	return m.DB.Exec("CREATE UNIQUE INDEX ON ??", m.CurrentTable(stmt), []interface{}{clause.Column{Name: field.DBName}}).Error
+}
+
 // GetIndexes returns all indexes for the table referenced by dst.
 func (m Migrator) GetIndexes(dst interface{}) ([]gorm.Index, error) {
 	var indexes []gorm.Index
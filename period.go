@@ -0,0 +1,218 @@
+package immudbGorm
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// periodClauseName is the key under which a period is stored in the clauses
+// of a statement. The clause is never built on its own, instead it is added
+// to every table reference by the FROM clause builder of the dialector.
+const periodClauseName = "IMMUDB_PERIOD"
+
+// PeriodInstant is one boundary of a period. The boundary is either
+// defined by a transaction id or by a point in time.
+type PeriodInstant struct {
+	// Tx is the id of the immudb transaction marking the boundary.
+	Tx uint64
+	// Time is the point in time marking the boundary.
+	// It is only used if it is not zero.
+	Time time.Time
+	// Exclusive excludes the boundary itself from the period.
+	// For the start of a period AFTER is used instead of SINCE and
+	// for the end of a period BEFORE is used instead of UNTIL.
+	Exclusive bool
+}
+
+// Period restricts the rows of a table to the state they had during a range
+// of immudb transactions or points in time. Either boundary of the period may
+// be omitted.
+type Period struct {
+	Start *PeriodInstant
+	End   *PeriodInstant
+}
+
+// Name returns the name of the clause.
+func (period Period) Name() string {
+	return periodClauseName
+}
+
+// Build writes the period in the immudb syntax, e.g. SINCE TX 1 UNTIL TX 5.
+func (period Period) Build(builder clause.Builder) {
+	if period.Start != nil {
+		if period.Start.Exclusive {
+			builder.WriteString("AFTER ")
+		} else {
+			builder.WriteString("SINCE ")
+		}
+		period.Start.build(builder)
+	}
+	if period.End != nil {
+		if period.Start != nil {
+			builder.WriteByte(' ')
+		}
+		if period.End.Exclusive {
+			builder.WriteString("BEFORE ")
+		} else {
+			builder.WriteString("UNTIL ")
+		}
+		period.End.build(builder)
+	}
+}
+
+// MergeClause replaces any previously set period with this one.
+func (period Period) MergeClause(c *clause.Clause) {
+	c.Expression = period
+}
+
+// isEmpty returns true if the period does not restrict the table at all.
+func (period Period) isEmpty() bool {
+	return period.Start == nil && period.End == nil
+}
+
+// build writes the value of the boundary.
+func (instant PeriodInstant) build(builder clause.Builder) {
+	if !instant.Time.IsZero() {
+		builder.AddVar(builder, instant.Time)
+		return
+	}
+	builder.WriteString("TX ")
+	builder.WriteString(strconv.FormatUint(instant.Tx, 10))
+}
+
+// WithPeriod creates a scope reading all tables of a query during the given period.
+func WithPeriod(period Period) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(period)
+	}
+}
+
+// AsOfTx creates a scope reading all tables of a query in the state they had
+// right after the transaction tx was committed.
+func AsOfTx(tx uint64) func(*gorm.DB) *gorm.DB {
+	return WithPeriod(Period{End: &PeriodInstant{Tx: tx}})
+}
+
+// BeforeTx creates a scope reading all tables of a query in the state they had
+// right before the transaction tx was committed.
+func BeforeTx(tx uint64) func(*gorm.DB) *gorm.DB {
+	return WithPeriod(Period{End: &PeriodInstant{Tx: tx, Exclusive: true}})
+}
+
+// AsOfTime creates a scope reading all tables of a query in the state they
+// had at the point in time t.
+func AsOfTime(t time.Time) func(*gorm.DB) *gorm.DB {
+	return WithPeriod(Period{End: &PeriodInstant{Time: t}})
+}
+
+// Between creates a scope reading the rows of the tables of a query, which
+// were written between from and to. Each row is returned with the latest
+// value written to it during this range. Rows not written during the range
+// are not returned, neither are rows whose latest write was a deletion.
+func Between(from, to time.Time) func(*gorm.DB) *gorm.DB {
+	return WithPeriod(Period{Start: &PeriodInstant{Time: from}, End: &PeriodInstant{Time: to}})
+}
+
+// periodOf returns the period set for a statement. A period set by a scope
+// takes precedence over one set in the context of the statement.
+func periodOf(stmt *gorm.Statement) (Period, bool) {
+	if c, ok := stmt.Clauses[periodClauseName]; ok {
+		if period, ok := c.Expression.(Period); ok && !period.isEmpty() {
+			return period, true
+		}
+	}
+	if stmt.Context != nil {
+		if period, ok := stmt.Context.Value(periodContextKey{}).(Period); ok && !period.isEmpty() {
+			return period, true
+		}
+	}
+	return Period{}, false
+}
+
+// buildFrom writes the FROM clause of a statement. In contrast to the
+// default FROM clause of gorm, the period of the statement is added to every
+// table reference, including the ones of joins, and aliases of tables are
+// introduced by AS.
+func buildFrom(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	from, ok := c.Expression.(clause.From)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	// Periods and joins are only supported for queries, e.g. not for deletes.
+	if _, ok := stmt.Clauses["SELECT"]; !ok {
+		c.Build(builder)
+		return
+	}
+	var period *Period
+	if p, ok := periodOf(stmt); ok {
+		period = &p
+	}
+
+	builder.WriteString("FROM ")
+	if len(from.Tables) > 0 {
+		for idx, table := range from.Tables {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			writeTable(stmt, table, period)
+		}
+	} else {
+		writeTable(stmt, clause.Table{Name: clause.CurrentTable}, period)
+	}
+
+	for _, join := range from.Joins {
+		builder.WriteByte(' ')
+		// Joins defined by a raw SQL expression cannot be altered, only
+		// joins on a table are read during the period.
+		if join.Expression != nil {
+			join.Build(builder)
+			continue
+		}
+		if join.Type != "" {
+			builder.WriteString(string(join.Type))
+			builder.WriteByte(' ')
+		}
+		builder.WriteString("JOIN ")
+		writeTable(stmt, join.Table, period)
+		if len(join.ON.Exprs) > 0 {
+			builder.WriteString(" ON ")
+			join.ON.Build(builder)
+		} else if len(join.Using) > 0 {
+			builder.WriteString(" USING (")
+			for idx, column := range join.Using {
+				if idx > 0 {
+					builder.WriteByte(',')
+				}
+				builder.WriteQuoted(column)
+			}
+			builder.WriteByte(')')
+		}
+	}
+}
+
+// writeTable writes a table reference with the period placed between the
+// table name and its alias, as expected by immudb. immudb requires the alias
+// to be introduced by AS. If period is nil, the table is read in its current
+// state.
+func writeTable(stmt *gorm.Statement, table clause.Table, period *Period) {
+	alias := table.Alias
+	table.Alias = ""
+	stmt.WriteQuoted(table)
+	if period != nil {
+		stmt.WriteByte(' ')
+		period.Build(stmt)
+	}
+	if alias != "" {
+		stmt.WriteString(" AS ")
+		stmt.WriteQuoted(clause.Table{Name: alias, Raw: table.Raw})
+	}
+}
@@ -0,0 +1,424 @@
+package immudbGorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"gorm.io/gorm"
+)
+
+// ErrProofUnsupported is returned by Prove, if no immudb client is available
+// to request proofs from the server. This is the case for embedded
+// databases.
+var ErrProofUnsupported = errors.New("creating proofs for rows requires an immudb client")
+
+// Proof is a self-contained proof, that a row with specific values has been
+// stored in an immudb transaction and that this transaction is part of the
+// history of a signed database state. It can be serialized as JSON and
+// checked with VerifyProof without a connection to the database.
+//
+// The proof covers the key and the value of the entry immudb stored for the
+// row. The key contains the ids of the database and the table and the
+// primary key of the row, the value contains the values of all columns
+// identified by their ids. The names and types of the table and its columns
+// are taken from the catalog of the server when the proof is created. They
+// are needed to decode the entry, but they are not covered by the proof.
+// Verified reads therefore pin the ids of tables and columns on first use.
+type Proof struct {
+	// Table is the name of the table containing the row.
+	Table string `json:"table"`
+	// DatabaseID and TableID are the ids immudb assigned to the database
+	// and the table.
+	DatabaseID uint32 `json:"databaseId"`
+	TableID    uint32 `json:"tableId"`
+	// Columns describes the columns of the table.
+	Columns []ProofColumn `json:"columns"`
+	// PrimaryKey contains the ids of the columns of the primary key.
+	PrimaryKey []uint32 `json:"primaryKey"`
+	// MaxColumnID is the largest id assigned to a column of the table so
+	// far, including columns which have been dropped.
+	MaxColumnID uint32 `json:"maxColumnId"`
+	// Entry is the key value entry immudb stored for the row.
+	Entry ProofEntry `json:"entry"`
+	// Tx is the header of the transaction containing the entry.
+	Tx TxHeader `json:"tx"`
+	// Inclusion proves that the entry is part of the transaction.
+	Inclusion InclusionProof `json:"inclusion"`
+	// Dual proves that the transaction is part of the history of the state.
+	// It is omitted if the state is the state at the transaction.
+	Dual *DualProof `json:"dual,omitempty"`
+	// State is the signed state of the database the proof leads to.
+	State State `json:"state"`
+}
+
+// ProofColumn describes a column of the table of a proof.
+type ProofColumn struct {
+	Name string `json:"name"`
+	// ID is the id immudb assigned to the column.
+	ID uint32 `json:"id"`
+	// Type is the immudb type of the column, e.g. INTEGER or VARCHAR.
+	Type string `json:"type"`
+	// MaxLength is the maximum length of values of the column, which is
+	// used to encode them in keys.
+	MaxLength int `json:"maxLength"`
+}
+
+// ProofEntry is a key value entry stored in an immudb transaction.
+type ProofEntry struct {
+	Key      []byte         `json:"key"`
+	Value    []byte         `json:"value"`
+	Metadata *EntryMetadata `json:"metadata,omitempty"`
+}
+
+// EntryMetadata is the metadata immudb stored with an entry.
+type EntryMetadata struct {
+	Deleted      bool `json:"deleted,omitempty"`
+	NonIndexable bool `json:"nonIndexable,omitempty"`
+	// ExpiresAt is the time the entry expires at in seconds since the
+	// epoch. It is 0 for entries which do not expire.
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// TxHeader is the header of an immudb transaction.
+type TxHeader struct {
+	ID       uint64      `json:"id"`
+	Ts       int64       `json:"ts"`
+	BlTxID   uint64      `json:"blTxId"`
+	BlRoot   []byte      `json:"blRoot"`
+	PrevAlh  []byte      `json:"prevAlh"`
+	Version  int         `json:"version"`
+	Metadata *TxMetadata `json:"metadata,omitempty"`
+	NEntries int         `json:"nEntries"`
+	Eh       []byte      `json:"eh"`
+}
+
+// TxMetadata is the metadata of an immudb transaction.
+type TxMetadata struct {
+	TruncatedTxID uint64 `json:"truncatedTxId,omitempty"`
+	Extra         []byte `json:"extra,omitempty"`
+}
+
+// InclusionProof proves that an entry is part of a transaction.
+type InclusionProof struct {
+	Leaf  int      `json:"leaf"`
+	Width int      `json:"width"`
+	Terms [][]byte `json:"terms"`
+}
+
+// LinearProof proves that a transaction directly follows a chain of
+// transactions.
+type LinearProof struct {
+	SourceTxID uint64   `json:"sourceTxId"`
+	TargetTxID uint64   `json:"targetTxId"`
+	Terms      [][]byte `json:"terms"`
+}
+
+// LinearAdvanceProof proves that the transactions covered by a linear proof
+// are part of the binary linking tree of the target transaction.
+type LinearAdvanceProof struct {
+	LinearProofTerms [][]byte   `json:"linearProofTerms"`
+	InclusionProofs  [][][]byte `json:"inclusionProofs"`
+}
+
+// DualProof proves that a source transaction is part of the history of a
+// target transaction.
+type DualProof struct {
+	SourceTxHeader     TxHeader            `json:"sourceTxHeader"`
+	TargetTxHeader     TxHeader            `json:"targetTxHeader"`
+	InclusionProof     [][]byte            `json:"inclusionProof"`
+	ConsistencyProof   [][]byte            `json:"consistencyProof"`
+	TargetBlTxAlh      []byte              `json:"targetBlTxAlh"`
+	LastInclusionProof [][]byte            `json:"lastInclusionProof"`
+	LinearProof        LinearProof         `json:"linearProof"`
+	LinearAdvanceProof *LinearAdvanceProof `json:"linearAdvanceProof,omitempty"`
+}
+
+// Prove creates a proof for the current state of the row of a model. The row
+// is identified by the primary key of the model.
+func Prove(db *gorm.DB, model interface{}) (*Proof, error) {
+	c, err := immuClientOf(db, ErrProofUnsupported)
+	if err != nil {
+		return nil, err
+	}
+	stmt := &gorm.Statement{DB: db, Context: db.Statement.Context}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	value := reflect.Indirect(reflect.ValueOf(model))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("a proof can only be created for a single model, but %T was given", model)
+	}
+	if len(stmt.Schema.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("a proof for table %s cannot be created as it does not have a primary key", stmt.Table)
+	}
+	pk := make([]driver.Value, len(stmt.Schema.PrimaryFields))
+	for i, field := range stmt.Schema.PrimaryFields {
+		v, isZero := field.ValueOf(stmt.Context, value)
+		if isZero {
+			return nil, fmt.Errorf("a proof for table %s cannot be created as the primary key %s is not set", stmt.Table, field.Name)
+		}
+		if pk[i], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := c.CurrentState(stmt.Context)
+	if err != nil {
+		return nil, err
+	}
+	return proveRow(stmt.Context, c, state, stmt.Table, pk)
+}
+
+// proveRow requests a proof for the row of a table identified by the values
+// of its primary key. The proof leads to the state given, unless the row has
+// been changed after it. In this case it leads to the state at the
+// transaction which changed the row.
+func proveRow(ctx context.Context, c client.ImmuClient, state *schema.ImmutableState, table string, pk []driver.Value) (*Proof, error) {
+	pkValues, err := sqlValuesOf(pk)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.GetServiceClient().VerifiableSQLGet(ctx, &schema.VerifiableSQLGetRequest{
+		SqlGetRequest: &schema.SQLGetRequest{Table: table, PkValues: pkValues},
+		ProveSinceTx:  state.TxId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entry.GetVerifiableTx().GetDualProof() == nil {
+		return nil, fmt.Errorf("%w: the server did not return a dual proof", ErrInvalidProof)
+	}
+
+	// Servers before immudb 1.4 do not include the proof linking the
+	// transactions covered by the linear proof in dual proofs.
+	dual := entry.VerifiableTx.DualProof
+	if dual.LinearAdvanceProof == nil && dual.SourceTxHeader != nil && dual.TargetTxHeader != nil {
+		proof := schema.DualProofFromProto(dual)
+		err := schema.FillMissingLinearAdvanceProof(ctx, proof, dual.SourceTxHeader.Id, dual.TargetTxHeader.Id, c.GetServiceClient())
+		if err != nil {
+			return nil, err
+		}
+		dual.LinearAdvanceProof = schema.LinearAdvanceProofToProto(proof.LinearAdvanceProof)
+	}
+	return NewProof(state.Db, table, entry)
+}
+
+// sqlValuesOf converts values into their representation in the immudb API.
+func sqlValuesOf(values []driver.Value) ([]*schema.SQLValue, error) {
+	converted := make([]*schema.SQLValue, len(values))
+	for i, v := range values {
+		var err error
+		if converted[i], err = schema.AsSQLValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return converted, nil
+}
+
+// NewProof creates a proof from an entry returned by the VerifiableSQLGet
+// call of the immudb API for a row of table in database. The proof leads to
+// the target of the dual proof of the entry, which is either the
+// transaction of the entry or the transaction given as ProveSinceTx in the
+// request, whichever is newer. The dual proof has to contain the linear
+// advance proof, see schema.FillMissingLinearAdvanceProof.
+func NewProof(database, table string, entry *schema.VerifiableSQLEntry) (*Proof, error) {
+	vtx := entry.GetVerifiableTx()
+	dual := vtx.GetDualProof()
+	if entry.GetSqlEntry() == nil || entry.GetInclusionProof() == nil || vtx.GetTx().GetHeader() == nil ||
+		dual.GetSourceTxHeader() == nil || dual.GetTargetTxHeader() == nil {
+		return nil, fmt.Errorf("%w: the entry is incomplete", ErrInvalidProof)
+	}
+	hdr := vtx.Tx.Header
+	if entry.SqlEntry.Tx != hdr.Id {
+		return nil, fmt.Errorf("%w: the entry is not part of transaction %d", ErrInvalidProof, hdr.Id)
+	}
+
+	proof := &Proof{
+		Table:       table,
+		DatabaseID:  entry.DatabaseId,
+		TableID:     entry.TableId,
+		PrimaryKey:  entry.PKIDs,
+		MaxColumnID: entry.MaxColId,
+		Entry: ProofEntry{
+			Value:    entry.SqlEntry.Value,
+			Metadata: entryMetadataFromProto(entry.SqlEntry.Metadata),
+		},
+		Tx: txHeaderFromProto(hdr),
+		Inclusion: InclusionProof{
+			Leaf:  int(entry.InclusionProof.Leaf),
+			Width: int(entry.InclusionProof.Width),
+			Terms: entry.InclusionProof.Terms,
+		},
+	}
+	for id, name := range entry.ColNamesById {
+		proof.Columns = append(proof.Columns, ProofColumn{
+			Name:      name,
+			ID:        id,
+			Type:      entry.ColTypesById[id],
+			MaxLength: int(entry.ColLenById[id]),
+		})
+	}
+	sort.Slice(proof.Columns, func(i, j int) bool { return proof.Columns[i].ID < proof.Columns[j].ID })
+
+	// The key of the entry returned by the server is the key the row is
+	// indexed with, the key of the entry in the transaction is derived from
+	// the primary key stored in the value.
+	row, err := proof.decodeRow()
+	if err != nil {
+		return nil, err
+	}
+	if proof.Entry.Key, err = proof.rowKey(row); err != nil {
+		return nil, err
+	}
+
+	// The state of the proof is the target of the dual proof, the server
+	// signed it.
+	target := schema.TxHeaderFromProto(dual.TargetTxHeader)
+	alh := target.Alh()
+	proof.State = State{Database: database, TxID: target.ID, TxHash: alh[:]}
+	if vtx.Signature != nil {
+		proof.State.Signature = vtx.Signature.Signature
+	}
+	if target.ID != hdr.Id {
+		if dual.SourceTxHeader.Id != hdr.Id {
+			return nil, fmt.Errorf("%w: the dual proof does not start at transaction %d", ErrInvalidProof, hdr.Id)
+		}
+		proof.Dual = dualProofFromProto(dual)
+	}
+	return proof, nil
+}
+
+// Values returns the decoded values of the row contained in the proof.
+// Null values are returned as nil.
+func (proof *Proof) Values() (map[string]interface{}, error) {
+	row, err := proof.decodeRow()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(proof.Columns))
+	for _, column := range proof.Columns {
+		values[column.Name] = nil
+		if v, ok := row[column.ID]; ok && !v.IsNull() {
+			values[column.Name] = v.RawValue()
+		}
+	}
+	return values, nil
+}
+
+// entryMetadataFromProto converts the metadata of an entry returned by the
+// immudb API.
+func entryMetadataFromProto(md *schema.KVMetadata) *EntryMetadata {
+	if md == nil {
+		return nil
+	}
+	metadata := &EntryMetadata{Deleted: md.Deleted, NonIndexable: md.NonIndexable}
+	if md.Expiration != nil {
+		metadata.ExpiresAt = md.Expiration.ExpiresAt
+	}
+	return metadata
+}
+
+// toProto converts the metadata into its representation in the immudb API.
+func (md *EntryMetadata) toProto() *schema.KVMetadata {
+	if md == nil {
+		return nil
+	}
+	metadata := &schema.KVMetadata{Deleted: md.Deleted, NonIndexable: md.NonIndexable}
+	if md.ExpiresAt != 0 {
+		metadata.Expiration = &schema.Expiration{ExpiresAt: md.ExpiresAt}
+	}
+	return metadata
+}
+
+// txHeaderFromProto converts a transaction header returned by the immudb API.
+func txHeaderFromProto(hdr *schema.TxHeader) TxHeader {
+	header := TxHeader{
+		ID:       hdr.Id,
+		Ts:       hdr.Ts,
+		BlTxID:   hdr.BlTxId,
+		BlRoot:   hdr.BlRoot,
+		PrevAlh:  hdr.PrevAlh,
+		Version:  int(hdr.Version),
+		NEntries: int(hdr.Nentries),
+		Eh:       hdr.EH,
+	}
+	if hdr.Metadata != nil {
+		header.Metadata = &TxMetadata{TruncatedTxID: hdr.Metadata.TruncatedTxID, Extra: hdr.Metadata.Extra}
+	}
+	return header
+}
+
+// toProto converts the header into its representation in the immudb API.
+func (hdr TxHeader) toProto() *schema.TxHeader {
+	header := &schema.TxHeader{
+		Id:       hdr.ID,
+		Ts:       hdr.Ts,
+		BlTxId:   hdr.BlTxID,
+		BlRoot:   hdr.BlRoot,
+		PrevAlh:  hdr.PrevAlh,
+		Version:  int32(hdr.Version),
+		Nentries: int32(hdr.NEntries),
+		EH:       hdr.Eh,
+	}
+	if hdr.Metadata != nil {
+		header.Metadata = &schema.TxMetadata{TruncatedTxID: hdr.Metadata.TruncatedTxID, Extra: hdr.Metadata.Extra}
+	}
+	return header
+}
+
+// dualProofFromProto converts a dual proof returned by the immudb API.
+func dualProofFromProto(proof *schema.DualProof) *DualProof {
+	dual := &DualProof{
+		SourceTxHeader:     txHeaderFromProto(proof.SourceTxHeader),
+		TargetTxHeader:     txHeaderFromProto(proof.TargetTxHeader),
+		InclusionProof:     proof.InclusionProof,
+		ConsistencyProof:   proof.ConsistencyProof,
+		TargetBlTxAlh:      proof.TargetBlTxAlh,
+		LastInclusionProof: proof.LastInclusionProof,
+	}
+	if proof.LinearProof != nil {
+		dual.LinearProof = LinearProof{
+			SourceTxID: proof.LinearProof.SourceTxId,
+			TargetTxID: proof.LinearProof.TargetTxId,
+			Terms:      proof.LinearProof.Terms,
+		}
+	}
+	if proof.LinearAdvanceProof != nil {
+		dual.LinearAdvanceProof = &LinearAdvanceProof{LinearProofTerms: proof.LinearAdvanceProof.LinearProofTerms}
+		for _, inclusion := range proof.LinearAdvanceProof.InclusionProofs {
+			dual.LinearAdvanceProof.InclusionProofs = append(dual.LinearAdvanceProof.InclusionProofs, inclusion.Terms)
+		}
+	}
+	return dual
+}
+
+// toProto converts the dual proof into its representation in the immudb
+// API.
+func (proof *DualProof) toProto() *schema.DualProof {
+	dual := &schema.DualProof{
+		SourceTxHeader:     proof.SourceTxHeader.toProto(),
+		TargetTxHeader:     proof.TargetTxHeader.toProto(),
+		InclusionProof:     proof.InclusionProof,
+		ConsistencyProof:   proof.ConsistencyProof,
+		TargetBlTxAlh:      proof.TargetBlTxAlh,
+		LastInclusionProof: proof.LastInclusionProof,
+		LinearProof: &schema.LinearProof{
+			SourceTxId: proof.LinearProof.SourceTxID,
+			TargetTxId: proof.LinearProof.TargetTxID,
+			Terms:      proof.LinearProof.Terms,
+		},
+	}
+	if proof.LinearAdvanceProof != nil {
+		dual.LinearAdvanceProof = &schema.LinearAdvanceProof{LinearProofTerms: proof.LinearAdvanceProof.LinearProofTerms}
+		for _, terms := range proof.LinearAdvanceProof.InclusionProofs {
+			dual.LinearAdvanceProof.InclusionProofs = append(dual.LinearAdvanceProof.InclusionProofs, &schema.InclusionProof{Terms: terms})
+		}
+	}
+	return dual
+}
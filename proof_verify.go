@@ -0,0 +1,191 @@
+package immudbGorm
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/sql"
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// ErrInvalidProof is returned by VerifyProof if a proof is not valid.
+var ErrInvalidProof = errors.New("the proof is not valid")
+
+// sqlPrefix is the prefix immudb uses for the keys of all entries created
+// by SQL statements.
+const sqlPrefix = byte(2)
+
+// VerifyProof checks that the entry contained in a proof is the row of the
+// primary key stored in the proof, that it has been stored in the
+// transaction of the proof and that the transaction is part of the history
+// of the state of the proof. If publicKey is not nil, the signature of the
+// state is checked as well. Otherwise the caller has to make sure the state
+// is trusted by other means, e.g. by comparing it with a state verified by
+// the client.
+//
+// The names and types of the columns are not covered by the proof, see
+// Proof. No connection to the database is required to verify a proof.
+func VerifyProof(proof *Proof, publicKey *ecdsa.PublicKey) error {
+	if proof == nil {
+		return fmt.Errorf("%w: no proof was given", ErrInvalidProof)
+	}
+	// The entry has to be the row of the primary key of the table.
+	row, err := proof.decodeRow()
+	if err != nil {
+		return err
+	}
+	key, err := proof.rowKey(row)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(key, proof.Entry.Key) {
+		return fmt.Errorf("%w: the entry is not the row of table %d with the primary key stored in it", ErrInvalidProof, proof.TableID)
+	}
+
+	// The entry has to be part of the transaction.
+	tx := schema.TxHeaderFromProto(proof.Tx.toProto())
+	entryDigest, err := store.EntrySpecDigestFor(tx.Version)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	entry := &store.EntrySpec{
+		Key:      proof.Entry.Key,
+		Metadata: schema.KVMetadataFromProto(proof.Entry.Metadata.toProto()),
+		Value:    proof.Entry.Value,
+	}
+	inclusion := schema.InclusionProofFromProto(&schema.InclusionProof{
+		Leaf:  int32(proof.Inclusion.Leaf),
+		Width: int32(proof.Inclusion.Width),
+		Terms: proof.Inclusion.Terms,
+	})
+	if !store.VerifyInclusion(inclusion, entryDigest(entry), tx.Eh) {
+		return fmt.Errorf("%w: the entry is not included in transaction %d", ErrInvalidProof, tx.ID)
+	}
+
+	// The transaction has to be part of the history of the state.
+	if len(proof.State.TxHash) != sha256.Size {
+		return fmt.Errorf("%w: the hash of the state is not a SHA-256 digest", ErrInvalidProof)
+	}
+	stateAlh := schema.DigestFromProto(proof.State.TxHash)
+	if tx.ID == proof.State.TxID {
+		if tx.Alh() != stateAlh {
+			return fmt.Errorf("%w: the transaction does not match the state", ErrInvalidProof)
+		}
+	} else {
+		if proof.Dual == nil {
+			return fmt.Errorf("%w: the proof does not link transaction %d to the state at transaction %d", ErrInvalidProof, tx.ID, proof.State.TxID)
+		}
+		dual := schema.DualProofFromProto(proof.Dual.toProto())
+		if !store.VerifyDualProof(dual, tx.ID, proof.State.TxID, tx.Alh(), stateAlh) {
+			return fmt.Errorf("%w: transaction %d is not part of the history of the state at transaction %d", ErrInvalidProof, tx.ID, proof.State.TxID)
+		}
+	}
+
+	// The state has to be signed by the server.
+	if publicKey != nil {
+		state := &schema.ImmutableState{
+			Db:        proof.State.Database,
+			TxId:      proof.State.TxID,
+			TxHash:    proof.State.TxHash,
+			Signature: &schema.Signature{Signature: proof.State.Signature},
+		}
+		if err := state.CheckSignature(publicKey); err != nil {
+			return fmt.Errorf("%w: the signature of the state is not valid", ErrInvalidProof)
+		}
+	}
+	return nil
+}
+
+// decodeRow decodes the values stored in the entry of the proof. The values
+// are returned by the ids of their columns. Values of columns which have
+// been dropped are skipped.
+func (proof *Proof) decodeRow() (map[uint32]sql.TypedValue, error) {
+	types := make(map[uint32]sql.SQLValueType, len(proof.Columns))
+	for _, column := range proof.Columns {
+		types[column.ID] = column.Type
+	}
+	b := proof.Entry.Value
+	if len(b) < sql.EncLenLen {
+		return nil, fmt.Errorf("%w: the value of the entry is not a row", ErrInvalidProof)
+	}
+	count := int(binary.BigEndian.Uint32(b))
+	b = b[sql.EncLenLen:]
+
+	row := make(map[uint32]sql.TypedValue, count)
+	for i := 0; i < count; i++ {
+		if len(b) < sql.EncIDLen {
+			return nil, fmt.Errorf("%w: the value of the entry is not a row", ErrInvalidProof)
+		}
+		id := binary.BigEndian.Uint32(b)
+		b = b[sql.EncIDLen:]
+		if _, ok := row[id]; ok {
+			return nil, fmt.Errorf("%w: the value of column %d is stored twice", ErrInvalidProof, id)
+		}
+
+		t, ok := types[id]
+		if !ok {
+			if id > proof.MaxColumnID {
+				return nil, fmt.Errorf("%w: the row contains the unknown column %d", ErrInvalidProof, id)
+			}
+			length, offset, err := sql.DecodeValueLength(b)
+			if err != nil {
+				return nil, fmt.Errorf("%w: the value of column %d cannot be decoded", ErrInvalidProof, id)
+			}
+			b = b[offset+length:]
+			continue
+		}
+		value, n, err := sql.DecodeValue(b, t)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the value of column %d cannot be decoded as %s", ErrInvalidProof, id, t)
+		}
+		row[id] = value
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("%w: the value of the entry is not a row", ErrInvalidProof)
+	}
+	return row, nil
+}
+
+// rowKey returns the key immudb stores a row with, which consists of the
+// ids of the database and the table and the encoded values of the primary
+// key of the row.
+func (proof *Proof) rowKey(row map[uint32]sql.TypedValue) ([]byte, error) {
+	if len(proof.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("%w: the table has no primary key", ErrInvalidProof)
+	}
+	var pk []byte
+	for _, id := range proof.PrimaryKey {
+		var column *ProofColumn
+		for i := range proof.Columns {
+			if proof.Columns[i].ID == id {
+				column = &proof.Columns[i]
+			}
+		}
+		if column == nil {
+			return nil, fmt.Errorf("%w: the primary key column %d is unknown", ErrInvalidProof, id)
+		}
+		value, ok := row[id]
+		if !ok || value.IsNull() {
+			return nil, fmt.Errorf("%w: the row has no value for the primary key column %s", ErrInvalidProof, column.Name)
+		}
+		encoded, _, err := sql.EncodeValueAsKey(value, column.Type, column.MaxLength)
+		if err != nil {
+			return nil, fmt.Errorf("%w: the primary key column %s cannot be encoded: %v", ErrInvalidProof, column.Name, err)
+		}
+		pk = append(pk, encoded...)
+	}
+	return sql.MapKey(
+		[]byte{sqlPrefix},
+		sql.RowPrefix,
+		sql.EncodeID(proof.DatabaseID),
+		sql.EncodeID(proof.TableID),
+		sql.EncodeID(sql.PKIndexID),
+		pk,
+	), nil
+}
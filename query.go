@@ -0,0 +1,147 @@
+package immudbGorm
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils"
+)
+
+// countDistinctSQL is the expression gorm selects for Count of a distinct
+// column.
+const countDistinctSQL = "COUNT(DISTINCT(?))"
+
+// countDistinct emulates counting the distinct values of a column, as immudb
+// does not support COUNT(DISTINCT column). The distinct values are selected
+// in a sub-query and counted by the outer query instead.
+func countDistinct(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.SQL.Len() != 0 {
+		return
+	}
+	c, ok := stmt.Clauses["SELECT"]
+	if !ok {
+		return
+	}
+	expr, ok := c.Expression.(clause.Expr)
+	if !ok || expr.SQL != countDistinctSQL || len(expr.Vars) != 1 {
+		return
+	}
+	column, ok := expr.Vars[0].(clause.Column)
+	if !ok {
+		return
+	}
+
+	// Build the sub-query selecting the distinct values with all conditions
+	// of the statement.
+	distinct := c
+	distinct.Expression = clause.Select{Distinct: true, Columns: []clause.Column{column}}
+	stmt.Clauses["SELECT"] = distinct
+	callbacks.BuildQuerySQL(db)
+	stmt.Clauses["SELECT"] = c
+	if db.Error != nil {
+		return
+	}
+
+	query := stmt.SQL.String()
+	stmt.SQL.Reset()
+	stmt.SQL.WriteString("SELECT COUNT(*) FROM (")
+	stmt.SQL.WriteString(query)
+	stmt.SQL.WriteString(")")
+}
+
+// query is the query callback of the dialector. It replaces the default
+// query callback of gorm to restore the names of columns read from joined
+// associations. gorm selects them with aliases like Company__name, which
+// immudb returns in lower case, as it does not distinguish the case of
+// identifiers. gorm would not assign them to the association otherwise.
+// Values of JSON columns are converted to the JSON text expected by the
+// fields as well.
+func query(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	callbacks.BuildQuerySQL(db)
+	if db.DryRun || db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+	rows, err := stmt.ConnPool.QueryContext(stmt.Context, stmt.SQL.String(), stmt.Vars...)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	defer func() {
+		db.AddError(rows.Close())
+	}()
+	var result gorm.Rows = rows
+	if d, ok := db.Dialector.(*dialector); ok && !d.DisableJSON && stmt.Schema != nil {
+		// Columns stored as VARCHAR already contain the JSON text.
+		columns, err := rows.Columns()
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		if isJSON, ok := jsonColumnsOf(stmt.Schema, columns); ok {
+			result = jsonRows{Rows: result, isJSON: isJSON}
+		}
+	}
+	if stmt.Schema != nil && len(stmt.Joins) > 0 {
+		result = joinedRows{Rows: result, schema: stmt.Schema}
+	}
+	gorm.Scan(result, db, 0)
+}
+
+// joinedRows are the rows of a query with joined associations.
+type joinedRows struct {
+	gorm.Rows
+	schema *schema.Schema
+}
+
+// Columns returns the names of the columns with the names of associations
+// written as they are defined in the schema.
+func (rows joinedRows) Columns() ([]string, error) {
+	columns, err := rows.Rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	for idx, column := range columns {
+		columns[idx] = joinedColumnName(rows.schema, column)
+	}
+	return columns, nil
+}
+
+// joinedColumnName restores the names of the associations a column of a
+// joined association is nested in, e.g. company__name becomes Company__name.
+func joinedColumnName(s *schema.Schema, column string) string {
+	names := utils.SplitNestedRelationName(column)
+	if len(names) < 2 {
+		return column
+	}
+	for idx, name := range names[:len(names)-1] {
+		relation := relationFold(s, name)
+		if relation == nil {
+			return column
+		}
+		names[idx] = relation.Name
+		s = relation.FieldSchema
+	}
+	return utils.JoinNestedRelationNames(names)
+}
+
+// relationFold returns the relationship of a schema, whose name matches name
+// ignoring the case.
+func relationFold(s *schema.Schema, name string) *schema.Relationship {
+	if relation, ok := s.Relationships.Relations[name]; ok {
+		return relation
+	}
+	for relationName, relation := range s.Relationships.Relations {
+		if strings.EqualFold(relationName, name) {
+			return relation
+		}
+	}
+	return nil
+}
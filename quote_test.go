@@ -0,0 +1,39 @@
+package immudbGorm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// quote returns an identifier quoted by the dialector.
+func quote(str string) string {
+	builder := &strings.Builder{}
+	dialector{Config: &Config{}}.QuoteTo(builder, str)
+	return builder.String()
+}
+
+func TestQuoteTo(t *testing.T) {
+
+	// Test cases
+	assert.Equal(t, `"users"`, quote("users"), "An identifier should be quoted")
+	assert.Equal(t, `"users"."name"`, quote("users.name"), "Every part of a qualified identifier should be quoted")
+	assert.Equal(t, `"company"."name"`, quote("Company.name"), "Identifiers should be written in lower case")
+	assert.Equal(t, `"users"."name"`, quote(`"users".name`), "Quoted identifiers should not be quoted again")
+	assert.Equal(t, `"users"."name"`, quote(`"users"."name"`), "Quoted identifiers should not be quoted again")
+}
+
+func TestQuoteToInvalidIdentifier(t *testing.T) {
+
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	require.NoError(t, err, "There was an error opening the dummy database")
+	stmt := &gorm.Statement{DB: db}
+	dialector{Config: &Config{}}.QuoteTo(stmt, `na"me`)
+
+	// Test cases
+	assert.ErrorIs(t, db.Error, ErrInvalidIdentifier, "An identifier containing a quote should be rejected")
+}
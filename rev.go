@@ -0,0 +1,240 @@
+package immudbGorm
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrRevisionConflict is returned by an update if the row has been modified
+// since the revision stored in the model was read.
+var ErrRevisionConflict = errors.New("the row has been modified since it was read, its revision does not match anymore")
+
+// revColumn is the name of the pseudo column containing the revision of a row.
+const revColumn = "_rev"
+
+// revFieldOf returns the field of a schema, which is tagged to contain the
+// revision of a row, e.g. `gorm:"-:migration" immudb:"rev"`.
+// If the schema does not have such a field, nil is returned.
+func revFieldOf(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	for _, field := range s.Fields {
+		if field.Tag.Get("immudb") == "rev" {
+			return field
+		}
+	}
+	return nil
+}
+
+// revValueOf returns the revision stored in a field of a model.
+func revValueOf(stmt *gorm.Statement, field *schema.Field, value reflect.Value) (uint64, bool) {
+	v, isZero := field.ValueOf(stmt.Context, value)
+	if isZero {
+		return 0, false
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), true
+	}
+	return 0, false
+}
+
+// buildSelect writes the SELECT clause of a statement. In contrast to the
+// default SELECT clause of gorm, the revision field of a model is read from
+// the _rev pseudo column. As SELECT * does not return the pseudo column, all
+// columns of a model are listed explicitly if it contains a revision field.
+// They are listed as well for distinct queries of all columns, as gorm drops
+// DISTINCT if no columns are selected.
+func buildSelect(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	sel, ok := c.Expression.(clause.Select)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	if stmt.Schema == nil {
+		if sel.Distinct && len(sel.Columns) == 0 {
+			builder.WriteString("SELECT DISTINCT *")
+			return
+		}
+		c.Build(builder)
+		return
+	}
+	if len(sel.Columns) == 0 {
+		if revFieldOf(stmt.Schema) == nil && !sel.Distinct {
+			c.Build(builder)
+			return
+		}
+		for _, name := range stmt.Schema.DBNames {
+			sel.Columns = append(sel.Columns, clause.Column{Table: clause.CurrentTable, Name: name})
+		}
+	}
+
+	columns := make([]clause.Column, len(sel.Columns))
+	for idx, column := range sel.Columns {
+		columns[idx] = revColumnOf(stmt, column)
+	}
+	sel.Columns = columns
+	c.Expression = sel
+	c.Build(builder)
+}
+
+// revColumnOf replaces a selected column with the _rev pseudo column, if it
+// refers to the revision field of the model or of a joined association.
+func revColumnOf(stmt *gorm.Statement, column clause.Column) clause.Column {
+	if column.Raw {
+		return column
+	}
+	s := stmt.Schema
+	if column.Table != "" && column.Table != clause.CurrentTable && column.Table != stmt.Table {
+		relation, ok := stmt.Schema.Relationships.Relations[column.Table]
+		if !ok {
+			return column
+		}
+		s = relation.FieldSchema
+	}
+	field := revFieldOf(s)
+	if field == nil || column.Name != field.DBName {
+		return column
+	}
+	column.Name = revColumn
+	if column.Alias == "" {
+		column.Alias = field.DBName
+	}
+	return column
+}
+
+// omitRevOnCreate excludes the revision field from an insert, as immudb
+// assigns the revision itself.
+func omitRevOnCreate(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	if field := revFieldOf(db.Statement.Schema); field != nil {
+		db.Statement.Omits = append(db.Statement.Omits, field.DBName)
+	}
+}
+
+// setRevAfterCreate sets the revision of newly created rows to 1. Inserts
+// handling conflicts may have replaced or skipped existing rows, which keep
+// counting their revisions. The revisions of their rows are read from the
+// database instead.
+func setRevAfterCreate(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	field := revFieldOf(db.Statement.Schema)
+	if field == nil {
+		return
+	}
+	stmt := db.Statement
+	mode, _ := onConflictModeOf(stmt)
+	setRev := func(value reflect.Value) {
+		if mode == onConflictFail {
+			db.AddError(field.Set(stmt.Context, value, 1))
+			return
+		}
+		rev, err := readRev(db, value)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		db.AddError(field.Set(stmt.Context, value, rev))
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			value := reflect.Indirect(stmt.ReflectValue.Index(i))
+			if value.Kind() == reflect.Struct {
+				setRev(value)
+			}
+		}
+	case reflect.Struct:
+		setRev(stmt.ReflectValue)
+	}
+}
+
+// readRev reads the current revision of the row of a model, identified by
+// the values of its primary key.
+func readRev(db *gorm.DB, value reflect.Value) (uint64, error) {
+	stmt := db.Statement
+	conditions := make([]clause.Expression, len(stmt.Schema.PrimaryFields))
+	for i, field := range stmt.Schema.PrimaryFields {
+		v, _ := field.ValueOf(stmt.Context, value)
+		conditions[i] = clause.Eq{Column: clause.Column{Name: field.DBName}, Value: v}
+	}
+	var rev uint64
+	err := db.Session(&gorm.Session{NewDB: true}).Raw(
+		"SELECT _rev FROM ? WHERE ?", clause.Table{Name: stmt.Table}, clause.And(conditions...),
+	).Row().Scan(&rev)
+	return rev, err
+}
+
+// checkRevOnUpdate excludes the revision field from the assignments of an
+// update and restricts the update to the revision stored in the model.
+func checkRevOnUpdate(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	field := revFieldOf(db.Statement.Schema)
+	if field == nil {
+		return
+	}
+	stmt := db.Statement
+	stmt.Omits = append(stmt.Omits, field.DBName)
+	if stmt.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	if rev, ok := revValueOf(stmt, field, stmt.ReflectValue); ok {
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Name: revColumn}, Value: rev},
+		}})
+		stmt.InstanceSet("immudb:rev", rev)
+	}
+}
+
+// checkRevAfterUpdate reports a conflict if an update restricted to a
+// revision did not change any row. Otherwise the revision stored in the
+// model is incremented to match the one assigned by immudb.
+func checkRevAfterUpdate(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	v, ok := db.InstanceGet("immudb:rev")
+	if !ok {
+		return
+	}
+	if db.RowsAffected == 0 {
+		db.AddError(ErrRevisionConflict)
+		return
+	}
+	field := revFieldOf(db.Statement.Schema)
+	db.AddError(field.Set(db.Statement.Context, db.Statement.ReflectValue, v.(uint64)+1))
+}
+
+// registerRevCallbacks registers the callbacks required to handle
+// revision fields of models.
+func registerRevCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("immudb:omit_rev", omitRevOnCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("immudb:set_rev", setRevAfterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("immudb:check_rev", checkRevOnUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Register("immudb:check_rev_conflict", checkRevAfterUpdate)
+}
@@ -0,0 +1,47 @@
+package immudbGorm
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrSnapshotUnsupported is returned by Snapshot, if no immudb client is
+// available to determine the latest committed transaction, e.g. for
+// embedded databases.
+var ErrSnapshotUnsupported = errors.New("a snapshot requires an immudb client to determine the current transaction")
+
+// periodContextKey is the context key for the period of a snapshot.
+type periodContextKey struct{}
+
+// Snapshot returns a session, which reads all tables in the state of the
+// database at the time the snapshot was created. Every query of the session,
+// including the ones for preloading associations, is pinned to this state.
+//
+// The snapshot is pinned to the latest transaction committed on the server,
+// which is retrieved with the immudb client of the database.
+func Snapshot(db *gorm.DB) (*gorm.DB, error) {
+	period, err := currentPeriod(db)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(db.Statement.Context, periodContextKey{}, period)
+	return db.WithContext(ctx), nil
+}
+
+// currentPeriod returns a period ending at the latest committed transaction.
+// The clock of the client is not used, as it may differ from the one of the
+// server and the timestamps of transactions only have a resolution of
+// seconds.
+func currentPeriod(db *gorm.DB) (Period, error) {
+	c, err := immuClientOf(db, ErrSnapshotUnsupported)
+	if err != nil {
+		return Period{}, err
+	}
+	state, err := c.CurrentState(db.Statement.Context)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{End: &PeriodInstant{Tx: state.TxId}}, nil
+}
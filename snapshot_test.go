@@ -0,0 +1,44 @@
+package immudbGorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// sessionOf returns a session of a database, which uses the dialector for
+// config without connecting to the database.
+func sessionOf(config *Config) *gorm.DB {
+	return &gorm.DB{
+		Config:    &gorm.Config{Dialector: newDialector(config)},
+		Statement: &gorm.Statement{Context: context.Background()},
+	}
+}
+
+func TestSnapshotPeriod(t *testing.T) {
+
+	// A client reporting transaction 42 as the latest one.
+	c := &stateClient{state: &schema.ImmutableState{Db: "test", TxId: 42}}
+	snapshot, err := Snapshot(sessionOf(&Config{Client: c}))
+	require.NoError(t, err, "There was an error creating the snapshot")
+
+	// Test cases
+	period, ok := snapshot.Statement.Context.Value(periodContextKey{}).(Period)
+	require.True(t, ok, "The snapshot should pin a period")
+	require.NotNil(t, period.End, "The period should end at the latest transaction")
+	assert.Equal(t, uint64(42), period.End.Tx, "The period should end at the transaction reported by the server")
+	assert.Nil(t, period.Start, "The period should not have a start")
+}
+
+func TestSnapshotWithoutClient(t *testing.T) {
+
+	// Embedded databases have no immudb client.
+	_, err := Snapshot(sessionOf(&Config{DriverName: "immudb", DSN: "immudbe:///tmp/test"}))
+
+	// Test cases
+	assert.ErrorIs(t, err, ErrSnapshotUnsupported, "A snapshot without client should be rejected")
+}
@@ -0,0 +1,258 @@
+package immudbGorm
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+)
+
+// ErrHistoryRewritten is returned if the current state of a database is not
+// consistent with the state trusted by the client. This happens if the
+// history of the database has been altered on the server.
+var ErrHistoryRewritten = errors.New("the state of the database is not consistent with the trusted state, its history has been rewritten")
+
+// ErrInvalidStateSignature is returned if the state of a database is not
+// signed with the private key of the server configured by ServerPublicKey.
+var ErrInvalidStateSignature = errors.New("the state of the database is not signed by the server")
+
+// State is a state of a database, which has been verified by the client.
+type State struct {
+	Database  string `json:"database"`
+	TxID      uint64 `json:"txId"`
+	TxHash    []byte `json:"txHash"`
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// StateStore persists the states trusted by the client, so that the
+// verification of a database continues from the last trusted state after
+// a restart.
+type StateStore interface {
+	// Load returns the trusted state stored for key. If no state has been
+	// stored so far, nil is returned.
+	Load(key string) (*State, error)
+	// Store saves the trusted state for key.
+	Store(key string, state *State) error
+}
+
+// FileStateStore stores trusted states as JSON files in a directory.
+type FileStateStore struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// NewFileStateStore creates a state store saving the states in dir.
+// The directory is created if it does not exist.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStateStore{Dir: dir}, nil
+}
+
+// Load returns the trusted state stored for key.
+func (store *FileStateStore) Load(key string) (*State, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	data, err := os.ReadFile(store.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Store saves the trusted state for key. The file is replaced atomically,
+// so that a crash does not leave a partially written state behind.
+func (store *FileStateStore) Store(key string, state *State) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(store.Dir, ".state-*")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), store.path(key))
+}
+
+// path returns the path of the file storing the state for key.
+func (store *FileStateStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(store.Dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// stateKeyOf returns the key under which the trusted state of the database
+// named database on the server at dsn is stored. Credentials are not part of
+// the key.
+func stateKeyOf(dsn string, database string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn + "#" + database
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = database
+	return u.String()
+}
+
+// serverOf returns the connection string of the server the client c is
+// connected to. It is used instead of the DSN for clients provided by the
+// application.
+func serverOf(c client.ImmuClient) string {
+	options := c.GetOptions()
+	if options == nil {
+		return ""
+	}
+	u := url.URL{Scheme: "immudb", Host: net.JoinHostPort(options.Address, fmt.Sprint(options.Port))}
+	return u.String()
+}
+
+// trustedState tracks the state trusted by the client for one database.
+type trustedState struct {
+	store StateStore
+	dsn   string
+	// publicKey is the public key of the server. If it is set, only states
+	// signed by the server are trusted.
+	publicKey *ecdsa.PublicKey
+
+	mu     sync.Mutex
+	loaded bool
+	key    string
+	state  *State
+
+	// catalog contains the ids of the tables and columns read with
+	// verification so far.
+	catalog pinnedCatalog
+}
+
+// newTrustedState creates a tracker for the trusted state of the database
+// at dsn. If store is nil, the state is only kept in memory.
+func newTrustedState(store StateStore, dsn string, publicKey *ecdsa.PublicKey) *trustedState {
+	return &trustedState{store: store, dsn: dsn, publicKey: publicKey}
+}
+
+// verify checks that the current state of the database is consistent with
+// the trusted state and trusts the current state afterwards. If a public key
+// of the server is configured, the current state must be signed by the
+// server. Otherwise, if no state is trusted yet, the current state is trusted
+// on first use. The new state is persisted if a store is configured. The new
+// trusted state is returned.
+func (t *trustedState) verify(ctx context.Context, c client.ImmuClient) (*State, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, err := c.CurrentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.publicKey != nil {
+		if err := current.CheckSignature(t.publicKey); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidStateSignature, err)
+		}
+	}
+
+	// The state is stored by the name of the database reported by the
+	// server, so that databases opened without a DSN do not share it.
+	if !t.loaded {
+		dsn := t.dsn
+		if dsn == "" {
+			dsn = serverOf(c)
+		}
+		t.key = stateKeyOf(dsn, current.Db)
+		if t.store != nil {
+			state, err := t.store.Load(t.key)
+			if err != nil {
+				return nil, err
+			}
+			t.state = state
+		}
+		t.loaded = true
+	}
+	if t.state != nil {
+		if t.state.Database != current.Db {
+			return nil, fmt.Errorf("the trusted state is a state of database %s, not of %s", t.state.Database, current.Db)
+		}
+		if err := verifyConsistency(ctx, c, t.state, current); err != nil {
+			return nil, err
+		}
+	}
+	t.state = &State{
+		Database:  current.Db,
+		TxID:      current.TxId,
+		TxHash:    current.TxHash,
+		Signature: current.GetSignature().GetSignature(),
+	}
+	if t.store != nil {
+		if err := t.store.Store(t.key, t.state); err != nil {
+			return nil, err
+		}
+	}
+	return t.state, nil
+}
+
+// verifyConsistency checks that the trusted state is part of the history of
+// the current state of a database.
+func verifyConsistency(ctx context.Context, c client.ImmuClient, trusted *State, current *schema.ImmutableState) error {
+	// A state older than the trusted one means transactions have been
+	// removed from the database.
+	if current.TxId < trusted.TxID {
+		return ErrHistoryRewritten
+	}
+	if current.TxId == trusted.TxID {
+		if !bytes.Equal(current.TxHash, trusted.TxHash) {
+			return ErrHistoryRewritten
+		}
+		return nil
+	}
+
+	tx, err := c.GetServiceClient().VerifiableTxById(ctx, &schema.VerifiableTxRequest{
+		Tx:           current.TxId,
+		ProveSinceTx: trusted.TxID,
+	})
+	if err != nil {
+		return err
+	}
+	if tx.GetDualProof() == nil {
+		return ErrHistoryRewritten
+	}
+	dual := schema.DualProofFromProto(tx.DualProof)
+	err = schema.FillMissingLinearAdvanceProof(ctx, dual, trusted.TxID, current.TxId, c.GetServiceClient())
+	if err != nil {
+		return err
+	}
+	if !store.VerifyDualProof(dual, trusted.TxID, current.TxId, schema.DigestFromProto(trusted.TxHash), schema.DigestFromProto(current.TxHash)) {
+		return ErrHistoryRewritten
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package immudbGorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"gorm.io/gorm"
+)
+
+// txIDSettingKey is the key under which the id of the immudb transaction
+// containing a write is stored in the settings of a statement.
+const txIDSettingKey = "immudb:tx_id"
+
+// txIDer is implemented by the results and transactions of a driver, which
+// report the id of the immudb transaction that committed the changes.
+type txIDer interface {
+	TxID() uint64
+}
+
+// txIDTargetsKey is the context key for the variables receiving the ids of
+// committed immudb transactions.
+type txIDTargetsKey struct{}
+
+// WithTxIDTarget returns a context, which stores the id of every immudb
+// transaction committed while using it in target. It can be used to retrieve
+// the transaction id of a db.Transaction block:
+//
+//	var txID uint64
+//	err := db.WithContext(immudbGorm.WithTxIDTarget(ctx, &txID)).Transaction(...)
+//
+// The target is left unchanged, if the driver does not report the ids of
+// committed transactions.
+func WithTxIDTarget(ctx context.Context, target *uint64) context.Context {
+	targets := txIDTargetsOf(ctx)
+	targets = append(targets[:len(targets):len(targets)], target)
+	return context.WithValue(ctx, txIDTargetsKey{}, targets)
+}
+
+// txIDTargetsOf returns the targets for transaction ids of a context.
+func txIDTargetsOf(ctx context.Context) []*uint64 {
+	if ctx == nil {
+		return nil
+	}
+	targets, _ := ctx.Value(txIDTargetsKey{}).([]*uint64)
+	return targets
+}
+
+// recordTxID stores the id of a committed transaction in all targets of the context.
+func recordTxID(ctx context.Context, id uint64) {
+	if id == 0 {
+		return
+	}
+	for _, target := range txIDTargetsOf(ctx) {
+		*target = id
+	}
+}
+
+// ErrTxIDUnavailable is returned by TxID, if the driver did not report the
+// id of the immudb transaction, which committed a write.
+var ErrTxIDUnavailable = errors.New("the driver did not report the id of the immudb transaction")
+
+// TxID returns the id of the immudb transaction, which contained the changes
+// of a create, update or delete operation.
+//
+//	result := db.Create(&user)
+//	txID, err := immudbGorm.TxID(result)
+//
+// Writes inside of a db.Transaction block are committed at the end of the
+// block, use WithTxIDTarget to retrieve the transaction id for them.
+//
+// The id is taken from the commit result of the driver. If the driver did
+// not report it, ErrTxIDUnavailable is returned. If the operation failed,
+// its error is returned.
+func TxID(db *gorm.DB) (uint64, error) {
+	if db.Error != nil {
+		return 0, db.Error
+	}
+	v, ok := db.Get(txIDSettingKey)
+	if !ok {
+		return 0, ErrTxIDUnavailable
+	}
+	id, ok := v.(uint64)
+	if !ok || id == 0 {
+		return 0, ErrTxIDUnavailable
+	}
+	return id, nil
+}
+
+// captureTxID adds a target for the transaction id to the context of a write
+// operation. It has to run before the transaction of the operation is begun.
+func captureTxID(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	id := new(uint64)
+	db.Statement.Context = WithTxIDTarget(db.Statement.Context, id)
+	db.InstanceSet(txIDSettingKey, id)
+}
+
+// storeTxID stores the transaction id of a write operation in the settings of
+// the statement, after the transaction of the operation has been committed.
+func storeTxID(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	v, ok := db.InstanceGet(txIDSettingKey)
+	if !ok {
+		return
+	}
+	id := *v.(*uint64)
+	if id != 0 {
+		db.Statement.Settings.Store(txIDSettingKey, id)
+	}
+}
+
+// registerTxIDCallbacks registers the callbacks capturing the transaction ids
+// of create, update and delete operations.
+func registerTxIDCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:begin_transaction").Register("immudb:capture_tx_id", captureTxID); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("immudb:store_tx_id", storeTxID); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:begin_transaction").Register("immudb:capture_tx_id", captureTxID); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("immudb:store_tx_id", storeTxID); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:begin_transaction").Register("immudb:capture_tx_id", captureTxID); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("immudb:store_tx_id", storeTxID)
+}
+
+// -- Driver wrapper --
+
+// dsnConnector is a connector for drivers, which do not provide one themselves.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// txIDConnector wraps the connections of a driver to record the ids of the
+// immudb transactions committed by them.
+type txIDConnector struct {
+	driver.Connector
+	// session is the client session of the database, which is closed
+	// together with the connection pool. It may be nil.
+	session *clientSession
+}
+
+// newTxIDConnector creates a connector for the dsn, which records the ids
+// of committed immudb transactions.
+func newTxIDConnector(d driver.Driver, dsn string, session *clientSession) (driver.Connector, error) {
+	if dc, ok := d.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return txIDConnector{Connector: connector, session: session}, nil
+	}
+	return txIDConnector{Connector: dsnConnector{dsn: dsn, driver: d}, session: session}, nil
+}
+
+// Close is called by database/sql when the connection pool is closed. It
+// closes the client session and the connector of the driver.
+func (c txIDConnector) Close() error {
+	var err error
+	if c.session != nil {
+		err = c.session.Close()
+	}
+	if closer, ok := c.Connector.(io.Closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (c txIDConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &txIDConn{Conn: conn}, nil
+}
+
+// txIDConn forwards all calls to the connection of the driver. Results of
+// executed statements and committed transactions are checked for the id of
+// the immudb transaction, which is recorded in the context.
+type txIDConn struct {
+	driver.Conn
+}
+
+func (c *txIDConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *txIDConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txIDTx{Tx: tx, ctx: ctx}, nil
+}
+
+func (c *txIDConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := result.(txIDer); ok {
+		recordTxID(ctx, t.TxID())
+	}
+	return result, nil
+}
+
+func (c *txIDConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *txIDConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *txIDConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *txIDConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *txIDConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// txIDTx records the id of the immudb transaction after a commit in the
+// context used to begin the transaction.
+type txIDTx struct {
+	driver.Tx
+	ctx context.Context
+}
+
+func (tx *txIDTx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+	if t, ok := tx.Tx.(txIDer); ok {
+		recordTxID(tx.ctx, t.TxID())
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package immudbGorm
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// uint64SerializerName is the name the Uint64Serializer is registered with,
+// e.g. `gorm:"serializer:immudb_uint64"`.
+const uint64SerializerName = "immudb_uint64"
+
+func init() {
+	schema.RegisterSerializer(uint64SerializerName, Uint64Serializer{})
+}
+
+// ErrUintOverflow is returned if an unsigned integer is written to an INTEGER
+// column, which cannot hold it. INTEGER columns of immudb are signed 64-bit
+// integers.
+type ErrUintOverflow struct {
+	Value uint64
+}
+
+func (err *ErrUintOverflow) Error() string {
+	return fmt.Sprintf("the value %d exceeds the range of immudb INTEGER columns, store the field with `gorm:\"serializer:%s\"` instead", err.Value, uint64SerializerName)
+}
+
+// checkUintRange returns an error if v is an unsigned integer, which exceeds
+// the range of INTEGER columns.
+func checkUintRange(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		if n := rv.Uint(); n > math.MaxInt64 {
+			return &ErrUintOverflow{Value: n}
+		}
+	}
+	return nil
+}
+
+// Uint64Serializer stores unsigned integers in BLOB[8] columns, so that the
+// full range of uint64 can be used. The values are encoded in big-endian byte
+// order, which keeps their order when the column is sorted or indexed.
+type Uint64Serializer struct{}
+
+// isUint64Field returns true if the values of a field are stored by the
+// Uint64Serializer, unless another type is set explicitly.
+func isUint64Field(field *schema.Field) bool {
+	if _, ok := field.TagSettings["TYPE"]; ok {
+		return false
+	}
+	switch field.Serializer.(type) {
+	case Uint64Serializer, *Uint64Serializer:
+		return true
+	}
+	return false
+}
+
+// Value encodes the value of a field.
+func (Uint64Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(fieldValue)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		return nil, fmt.Errorf("the field %s of type %s cannot be stored as an unsigned integer", field.Name, field.FieldType)
+	}
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, rv.Uint())
+	return encoded, nil
+}
+
+// Scan decodes a value of the database into a field.
+func (Uint64Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+	if dbValue != nil {
+		var n uint64
+		switch v := dbValue.(type) {
+		case []byte:
+			if len(v) != 8 {
+				return fmt.Errorf("the value of %s has %d bytes instead of the 8 bytes of an unsigned integer", field.Name, len(v))
+			}
+			n = binary.BigEndian.Uint64(v)
+		case int64:
+			// Values of columns, which were created as INTEGER.
+			if v < 0 {
+				return fmt.Errorf("the value %d of %s is not an unsigned integer", v, field.Name)
+			}
+			n = uint64(v)
+		default:
+			return fmt.Errorf("the value of %s of type %T cannot be read as an unsigned integer", field.Name, dbValue)
+		}
+
+		target := fieldValue.Elem()
+		if target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+		switch target.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return fmt.Errorf("the field %s of type %s cannot be read as an unsigned integer", field.Name, field.FieldType)
+		}
+		if target.OverflowUint(n) {
+			return fmt.Errorf("the value %d of %s overflows the type %s", n, field.Name, target.Type())
+		}
+		target.SetUint(n)
+	}
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
@@ -0,0 +1,108 @@
+package immudbGorm
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+)
+
+// updateClauses are the clauses of an UPDATE statement supported by immudb.
+var updateClauses = []string{"UPDATE", "SET", "WHERE"}
+
+// update is the update callback of the dialector. It replaces the default
+// update callback of gorm, which builds statements immudb cannot execute:
+//   - immudb does not support ORDER BY and LIMIT for updates. They are
+//     rejected instead of being silently ignored, which would update more
+//     rows than intended.
+//   - immudb cannot change the primary key of a row. Assignments of the
+//     primary key to its current value, which gorm creates e.g. for Updates
+//     with a struct of another instance, are skipped. Other changes of the
+//     primary key are rejected.
+//
+// Save of a model, which does not exist yet, updates no rows. gorm then
+// creates the model with an OnConflict clause, which is written as UPSERT.
+func update(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+
+	if stmt.Schema != nil {
+		for _, c := range stmt.Schema.UpdateClauses {
+			stmt.AddClause(c)
+		}
+	}
+
+	if stmt.SQL.Len() == 0 {
+		for _, name := range []string{"ORDER BY", "LIMIT"} {
+			if _, ok := stmt.Clauses[name]; ok {
+				db.AddError(&ErrUnsupportedClause{Clause: name, Reason: "immudb does not support " + name + " for updates"})
+				return
+			}
+		}
+
+		stmt.SQL.Grow(180)
+		stmt.AddClauseIfNotExists(clause.Update{})
+		if _, ok := stmt.Clauses["SET"]; !ok {
+			set, err := withoutPrimaryKeyAssignments(stmt, callbacks.ConvertToAssignments(stmt))
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			if len(set) == 0 {
+				return
+			}
+			defer delete(stmt.Clauses, "SET")
+			stmt.AddClause(set)
+		}
+
+		stmt.Build(stmt.BuildClauses...)
+	}
+
+	// Updates without conditions are only allowed if they are requested
+	// explicitly, the same as for the default update callback. The condition
+	// added for soft deletes does not count.
+	if !db.AllowGlobalUpdate && db.Error == nil {
+		where, withCondition := stmt.Clauses["WHERE"]
+		if _, withSoftDelete := stmt.Clauses["soft_delete_enabled"]; withCondition && withSoftDelete {
+			whereClause, _ := where.Expression.(clause.Where)
+			withCondition = len(whereClause.Exprs) > 1
+		}
+		if !withCondition {
+			db.AddError(gorm.ErrMissingWhereClause)
+		}
+	}
+
+	if !db.DryRun && db.Error == nil {
+		result, err := stmt.ConnPool.ExecContext(stmt.Context, stmt.SQL.String(), stmt.Vars...)
+		if db.AddError(err) == nil {
+			db.RowsAffected, _ = result.RowsAffected()
+		}
+	}
+}
+
+// withoutPrimaryKeyAssignments removes the assignments of the primary key
+// from set, if they assign its current value. An error is returned if the
+// primary key would be changed.
+func withoutPrimaryKeyAssignments(stmt *gorm.Statement, set clause.Set) (clause.Set, error) {
+	if stmt.Schema == nil {
+		return set, nil
+	}
+	filtered := make(clause.Set, 0, len(set))
+	for _, assignment := range set {
+		field := stmt.Schema.LookUpField(assignment.Column.Name)
+		if field == nil || !field.PrimaryKey {
+			filtered = append(filtered, assignment)
+			continue
+		}
+		if stmt.ReflectValue.Kind() == reflect.Struct {
+			if current, isZero := field.ValueOf(stmt.Context, stmt.ReflectValue); !isZero && reflect.DeepEqual(current, assignment.Value) {
+				continue
+			}
+		}
+		return nil, &ErrUnsupportedClause{Clause: "SET", Reason: "immudb cannot change the primary key " + field.DBName + " of a row"}
+	}
+	return filtered, nil
+}
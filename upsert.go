@@ -0,0 +1,159 @@
+package immudbGorm
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnsupportedClause is returned if a query uses a variant of a clause,
+// which cannot be expressed in immudb SQL.
+type ErrUnsupportedClause struct {
+	Clause string
+	Reason string
+}
+
+func (err *ErrUnsupportedClause) Error() string {
+	return "the clause " + err.Clause + " is not supported by immudb: " + err.Reason
+}
+
+// onConflictMode describes how immudb handles conflicts of an insert.
+type onConflictMode int
+
+const (
+	// onConflictFail lets the insert fail on conflicts, which is the default
+	// of INSERT INTO.
+	onConflictFail onConflictMode = iota
+	// onConflictDoNothing skips conflicting rows with ON CONFLICT DO NOTHING.
+	onConflictDoNothing
+	// onConflictUpsert replaces conflicting rows with UPSERT INTO.
+	onConflictUpsert
+)
+
+// onConflictModeOf determines how the ON CONFLICT clause of a statement can
+// be expressed in immudb SQL. immudb only detects conflicts of the primary
+// key and can either skip the conflicting row or replace it completely.
+func onConflictModeOf(stmt *gorm.Statement) (onConflictMode, error) {
+	c, ok := stmt.Clauses["ON CONFLICT"]
+	if !ok {
+		return onConflictFail, nil
+	}
+	onConflict, ok := c.Expression.(clause.OnConflict)
+	if !ok {
+		return onConflictFail, nil
+	}
+	unsupported := func(reason string) (onConflictMode, error) {
+		return onConflictFail, &ErrUnsupportedClause{Clause: "ON CONFLICT", Reason: reason}
+	}
+	if onConflict.OnConstraint != "" {
+		return unsupported("conflicts of constraints cannot be handled, immudb does not support constraints")
+	}
+	if len(onConflict.Where.Exprs) > 0 || len(onConflict.TargetWhere.Exprs) > 0 {
+		return unsupported("conflicts cannot be handled conditionally")
+	}
+	if !isPrimaryKey(stmt, onConflict.Columns) {
+		return unsupported("only conflicts of the primary key can be handled")
+	}
+	if onConflict.DoNothing {
+		return onConflictDoNothing, nil
+	}
+	// gorm has converted UpdateAll into assignments of all inserted columns
+	// already, except the ones it never updates like the creation time.
+	// These are replaced as well, as immudb always replaces the whole row.
+	if onConflict.UpdateAll {
+		return onConflictUpsert, nil
+	}
+	if !assignsAllInsertedColumns(stmt, onConflict.DoUpdates) {
+		return unsupported("immudb can only replace a conflicting row completely, all inserted columns have to be updated with their excluded values")
+	}
+	return onConflictUpsert, nil
+}
+
+// isPrimaryKey returns true if columns are the primary key of the schema of
+// a statement. No columns refer to the primary key as well.
+func isPrimaryKey(stmt *gorm.Statement, columns []clause.Column) bool {
+	if len(columns) == 0 {
+		return true
+	}
+	if stmt.Schema == nil || len(columns) != len(stmt.Schema.PrimaryFields) {
+		return false
+	}
+	for _, column := range columns {
+		field := stmt.Schema.LookUpField(column.Name)
+		if field == nil || !field.PrimaryKey {
+			return false
+		}
+	}
+	return true
+}
+
+// assignsAllInsertedColumns returns true if assignments set every inserted
+// column, except the primary key, to its excluded value.
+func assignsAllInsertedColumns(stmt *gorm.Statement, assignments clause.Set) bool {
+	c, ok := stmt.Clauses["VALUES"]
+	if !ok {
+		return false
+	}
+	values, ok := c.Expression.(clause.Values)
+	if !ok {
+		return false
+	}
+	excluded := map[string]bool{}
+	for _, assignment := range assignments {
+		column, ok := assignment.Value.(clause.Column)
+		if !ok || column.Table != "excluded" || column.Name != assignment.Column.Name {
+			return false
+		}
+		excluded[column.Name] = true
+	}
+	for _, column := range values.Columns {
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(column.Name); field != nil && field.PrimaryKey {
+				continue
+			}
+		}
+		if !excluded[column.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildInsert builds the INSERT clause of a statement. Inserts replacing
+// conflicting rows are written as UPSERT INTO.
+func buildInsert(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	if mode, _ := onConflictModeOf(stmt); mode != onConflictUpsert {
+		c.Build(builder)
+		return
+	}
+	insert, _ := c.Expression.(clause.Insert)
+	builder.WriteString("UPSERT INTO ")
+	if insert.Table.Name == "" {
+		builder.WriteQuoted(stmt.Table)
+	} else {
+		builder.WriteQuoted(insert.Table)
+	}
+}
+
+// buildOnConflict builds the ON CONFLICT clause of a statement. Only skipping
+// conflicting rows is written as a clause, replacing them is done by the
+// INSERT clause.
+func buildOnConflict(c clause.Clause, builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		c.Build(builder)
+		return
+	}
+	mode, err := onConflictModeOf(stmt)
+	if err != nil {
+		stmt.AddError(err)
+		return
+	}
+	if mode == onConflictDoNothing {
+		builder.WriteString("ON CONFLICT DO NOTHING")
+	}
+}
@@ -0,0 +1,316 @@
+package immudbGorm
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// verifySettingKey is the key of the setting enabling verified reads for a session.
+const verifySettingKey = "immudb:verify"
+
+// ErrVerificationUnsupported is returned for verified reads, if no immudb
+// client is available to request proofs from the server. This is the case
+// for embedded databases.
+var ErrVerificationUnsupported = errors.New("verifying rows requires an immudb client")
+
+// ErrVerificationFailed is returned if a row read from the database could not
+// be verified against the cryptographic proofs of immudb.
+type ErrVerificationFailed struct {
+	Table string
+	Err   error
+}
+
+func (err *ErrVerificationFailed) Error() string {
+	return "the verification of a row of table " + err.Table + " failed: " + err.Err.Error()
+}
+
+func (err *ErrVerificationFailed) Unwrap() error {
+	return err.Err
+}
+
+// maxProofAttempts is the number of times a proof is requested for a row,
+// which is changed while it is verified.
+const maxProofAttempts = 3
+
+// rowVerifier verifies rows against proofs leading to the trusted state of
+// a database.
+type rowVerifier struct {
+	client client.ImmuClient
+	trust  *trustedState
+	state  *State
+}
+
+// newRowVerifier verifies that the current state of the database is
+// consistent with the trusted state. The rows are verified against the
+// new trusted state afterwards.
+func newRowVerifier(ctx context.Context, c client.ImmuClient, trust *trustedState) (*rowVerifier, error) {
+	state, err := trust.verify(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return &rowVerifier{client: c, trust: trust, state: state}, nil
+}
+
+// verify checks that a row with the given values of columns is the row
+// identified by the values of its primary key in the trusted state of the
+// database.
+func (v *rowVerifier) verify(ctx context.Context, table string, pk []driver.Value, columns []string, values []driver.Value) error {
+	var proof *Proof
+	for attempt := 1; ; attempt++ {
+		var err error
+		proof, err = proveRow(ctx, v.client, &schema.ImmutableState{Db: v.state.Database, TxId: v.state.TxID}, table, pk)
+		if err != nil {
+			return err
+		}
+		if proof.State.TxID <= v.state.TxID || attempt == maxProofAttempts {
+			break
+		}
+		// The row has been changed after the state was verified, the
+		// proof leads to the state at the new transaction.
+		if v.state, err = v.trust.verify(ctx, v.client); err != nil {
+			return err
+		}
+	}
+	if proof.State.TxID != v.state.TxID || !bytes.Equal(proof.State.TxHash, v.state.TxHash) {
+		return fmt.Errorf("%w: the proof does not lead to the trusted state", ErrInvalidProof)
+	}
+	if err := VerifyProof(proof, nil); err != nil {
+		return err
+	}
+	if err := v.trust.catalog.check(proof); err != nil {
+		return err
+	}
+	if proof.Entry.Metadata != nil && proof.Entry.Metadata.Deleted {
+		return errors.New("the row has been deleted")
+	}
+
+	// The values are compared by the ids of their columns, which have been
+	// checked against the pinned catalog.
+	row, err := proof.decodeRow()
+	if err != nil {
+		return err
+	}
+	described := make(map[string]ProofColumn, len(proof.Columns))
+	for _, column := range proof.Columns {
+		described[column.Name] = column
+	}
+	for i, column := range columns {
+		c, ok := described[column]
+		if !ok {
+			return fmt.Errorf("the column %s is not part of the proven row", column)
+		}
+		var value interface{}
+		if v, ok := row[c.ID]; ok && !v.IsNull() {
+			value = v.RawValue()
+		}
+		if !equalValues(c.Type, value, values[i]) {
+			return fmt.Errorf("the value of column %s does not match the proven value", column)
+		}
+	}
+	return nil
+}
+
+// equalValues returns true if a value read from the database is equal to the
+// value decoded from a proof for a column of type columnType.
+func equalValues(columnType string, proven interface{}, read driver.Value) bool {
+	// JSON values are compared after decoding the value read. The decoded
+	// value of a proof may also be a scalar or null.
+	if columnType == "JSON" {
+		if read == nil {
+			return proven == nil
+		}
+		var r interface{}
+		switch v := read.(type) {
+		case string:
+			if err := json.Unmarshal([]byte(v), &r); err != nil {
+				return false
+			}
+		case []byte:
+			if err := json.Unmarshal(v, &r); err != nil {
+				return false
+			}
+		default:
+			return false
+		}
+		return reflect.DeepEqual(proven, r)
+	}
+	if proven == nil || read == nil {
+		return proven == nil && read == nil
+	}
+	switch p := proven.(type) {
+	case time.Time:
+		r, ok := read.(time.Time)
+		return ok && p.Equal(r.Truncate(time.Microsecond))
+	case uuid.UUID:
+		switch r := read.(type) {
+		case string:
+			u, err := uuid.Parse(r)
+			return err == nil && u == p
+		case []byte:
+			u, err := uuid.FromBytes(r)
+			return err == nil && u == p
+		}
+		return false
+	case []byte:
+		switch r := read.(type) {
+		case []byte:
+			return bytes.Equal(p, r)
+		case string:
+			return string(p) == r
+		}
+		return false
+	case string, int64, float64, bool:
+		if r, ok := read.([]byte); ok {
+			read = string(r)
+		}
+		return proven == read
+	}
+	return false
+}
+
+// Verified creates a scope verifying all rows returned by a query against
+// the cryptographic proofs of immudb. If a row cannot be verified, the query
+// returns an ErrVerificationFailed error.
+func Verified() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Set(verifySettingKey, true)
+	}
+}
+
+// verifyRows creates a callback verifying the rows returned by a query. If
+// verifyAll is false, only queries of sessions using the Verified scope are
+// verified. The state of the database is verified to be consistent with the
+// trusted state before the rows are verified.
+func verifyRows(verifyAll bool) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.DryRun || db.Statement.Schema == nil {
+			return
+		}
+		if !verifyAll {
+			if v, ok := db.Get(verifySettingKey); !ok || v != true {
+				return
+			}
+		}
+		var trust *trustedState
+		if d, ok := db.Dialector.(*dialector); ok {
+			trust = d.trust
+		}
+		db.AddError(verifyStatement(db, trust))
+	}
+}
+
+// verifyStatement verifies all models returned by the query of a statement.
+func verifyStatement(db *gorm.DB, trust *trustedState) error {
+	stmt := db.Statement
+	// Only rows of the model itself can be verified, e.g. not the results
+	// of aggregations.
+	columns := verifiableColumnsOf(stmt)
+	rows := modelValuesOf(stmt)
+	if len(columns) == 0 || len(rows) == 0 {
+		return nil
+	}
+	// The proofs of immudb only cover the current state of a row.
+	if _, ok := periodOf(stmt); ok {
+		return &ErrVerificationFailed{Table: stmt.Table, Err: errors.New("rows read during a period cannot be verified")}
+	}
+
+	c, err := immuClientOf(db, ErrVerificationUnsupported)
+	if err != nil {
+		return err
+	}
+	verifier, err := newRowVerifier(stmt.Context, c, trust)
+	if err != nil {
+		return &ErrVerificationFailed{Table: stmt.Table, Err: err}
+	}
+	for _, row := range rows {
+		pk := make([]driver.Value, len(stmt.Schema.PrimaryFields))
+		for i, field := range stmt.Schema.PrimaryFields {
+			v, _ := field.ValueOf(stmt.Context, row)
+			if pk[i], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+				return err
+			}
+		}
+		values := make([]driver.Value, len(columns))
+		for i, name := range columns {
+			v, _ := stmt.Schema.FieldsByDBName[name].ValueOf(stmt.Context, row)
+			if values[i], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+				return err
+			}
+		}
+		if err := verifier.verify(stmt.Context, stmt.Table, pk, columns, values); err != nil {
+			return &ErrVerificationFailed{Table: stmt.Table, Err: err}
+		}
+	}
+	return nil
+}
+
+// verifiableColumnsOf returns the columns of the model read by the query of
+// a statement. The revision of a row is not included, as it is not stored in
+// the row itself.
+func verifiableColumnsOf(stmt *gorm.Statement) []string {
+	c, ok := stmt.Clauses["SELECT"]
+	if !ok {
+		return nil
+	}
+	sel, ok := c.Expression.(clause.Select)
+	if !ok {
+		return nil
+	}
+	revField := revFieldOf(stmt.Schema)
+	columns := []string{}
+	if len(sel.Columns) == 0 {
+		for _, name := range stmt.Schema.DBNames {
+			if revField == nil || name != revField.DBName {
+				columns = append(columns, name)
+			}
+		}
+		return columns
+	}
+	for _, column := range sel.Columns {
+		if column.Raw || (column.Table != "" && column.Table != clause.CurrentTable && column.Table != stmt.Table) {
+			continue
+		}
+		if _, ok := stmt.Schema.FieldsByDBName[column.Name]; !ok {
+			continue
+		}
+		if revField == nil || column.Name != revField.DBName {
+			columns = append(columns, column.Name)
+		}
+	}
+	return columns
+}
+
+// modelValuesOf returns all models of the schema contained in the
+// destination of a statement.
+func modelValuesOf(stmt *gorm.Statement) []reflect.Value {
+	modelType := stmt.Schema.ModelType
+	value := stmt.ReflectValue
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == modelType {
+			return []reflect.Value{value}
+		}
+	case reflect.Slice, reflect.Array:
+		values := []reflect.Value{}
+		for i := 0; i < value.Len(); i++ {
+			elem := reflect.Indirect(value.Index(i))
+			if elem.Kind() == reflect.Struct && elem.Type() == modelType {
+				values = append(values, elem)
+			}
+		}
+		return values
+	}
+	return nil
+}
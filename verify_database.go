@@ -0,0 +1,187 @@
+package immudbGorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseReport is the result of verifying all rows of a database.
+//
+// Only the rows returned by the server are verified. A server omitting rows
+// or whole tables from its answers is not detected, the report therefore
+// does not prove that the database is complete.
+type DatabaseReport struct {
+	// State is the trusted state after the verification. It is nil if the
+	// state could not be verified.
+	State *State
+	// StateErr is set if the state of the database is not consistent with
+	// the previously trusted state. The rows are not verified in this case.
+	StateErr error
+	Tables   []TableReport
+}
+
+// TableReport is the result of verifying all rows of a table.
+type TableReport struct {
+	Table string
+	// Rows is the number of verified rows.
+	Rows     int
+	Failures []RowFailure
+}
+
+// RowFailure describes a row, which could not be verified.
+type RowFailure struct {
+	// PK contains the values of the primary key of the row.
+	PK  []interface{}
+	Err error
+}
+
+// OK returns true if the state and all rows of the database were verified
+// successfully.
+func (report *DatabaseReport) OK() bool {
+	if report.StateErr != nil {
+		return false
+	}
+	for _, table := range report.Tables {
+		if len(table.Failures) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyDatabase verifies that the current state of the database is
+// consistent with the previously trusted state and re-reads all rows of
+// every table with verification. Inconsistencies are collected in the
+// report instead of aborting the verification. An error is only returned if
+// the verification could not be performed at all.
+//
+// The tables and rows to verify are listed by the server. Rows or tables
+// omitted by the server are not detected.
+func VerifyDatabase(db *gorm.DB) (*DatabaseReport, error) {
+	d, ok := db.Dialector.(*dialector)
+	if !ok {
+		return nil, fmt.Errorf("the database does not use the immudb dialector")
+	}
+	c, err := immuClientOf(db, ErrVerificationUnsupported)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	report := &DatabaseReport{}
+
+	// Verify the state of the database first, the rows are verified
+	// against it afterwards.
+	ctx := db.Statement.Context
+	verifier, err := newRowVerifier(ctx, c, d.trust)
+	if err != nil {
+		report.StateErr = err
+		return report, nil
+	}
+	report.State = verifier.state
+
+	for _, table := range tables {
+		tableReport, err := verifyTable(db, table, verifier)
+		if err != nil {
+			return nil, err
+		}
+		report.Tables = append(report.Tables, *tableReport)
+	}
+	return report, nil
+}
+
+// verifyTable reads all rows of a table and verifies each of them. Rows not
+// returned by the server are not checked.
+func verifyTable(db *gorm.DB, table string, verifier *rowVerifier) (*TableReport, error) {
+	ctx := db.Statement.Context
+	report := &TableReport{Table: table}
+
+	// Determine the columns of the primary key.
+	indexes, err := db.Migrator().GetIndexes(table)
+	if err != nil {
+		return nil, err
+	}
+	var pkColumns []string
+	for _, index := range indexes {
+		if primary, _ := index.PrimaryKey(); primary {
+			pkColumns = index.Columns()
+		}
+	}
+	if len(pkColumns) == 0 {
+		return nil, fmt.Errorf("the primary key of table %s could not be determined", table)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Read all rows before verifying them, so that the connection is not
+	// kept busy while the proofs are requested.
+	rows, err := sqlDB.QueryContext(ctx, "SELECT * FROM "+db.Statement.Quote(table))
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	pkIndexes := make([]int, len(pkColumns))
+	for i, pkColumn := range pkColumns {
+		pkIndexes[i] = -1
+		for j, column := range columns {
+			if column == pkColumn {
+				pkIndexes[i] = j
+			}
+		}
+		if pkIndexes[i] < 0 {
+			rows.Close()
+			return nil, fmt.Errorf("the primary key column %s of table %s is not part of its rows", pkColumn, table)
+		}
+	}
+	var values [][]driver.Value
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range row {
+			targets[i] = &row[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		converted := make([]driver.Value, len(row))
+		for i, v := range row {
+			if converted[i], err = driver.DefaultParameterConverter.ConvertValue(v); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		values = append(values, converted)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, row := range values {
+		pk := make([]driver.Value, len(pkIndexes))
+		for i, idx := range pkIndexes {
+			pk[i] = row[idx]
+		}
+		report.Rows++
+		if err := verifier.verify(ctx, table, pk, columns, row); err != nil {
+			failure := RowFailure{Err: &ErrVerificationFailed{Table: table, Err: err}}
+			for _, v := range pk {
+				failure.PK = append(failure.PK, v)
+			}
+			report.Failures = append(report.Failures, failure)
+		}
+	}
+	return report, nil
+}
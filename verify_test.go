@@ -0,0 +1,56 @@
+package immudbGorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqualValues(t *testing.T) {
+
+	id := uuid.New()
+	now := time.Date(2024, 5, 1, 12, 30, 15, 123456789, time.UTC)
+
+	// Test cases
+	assert.True(t, equalValues("INTEGER", nil, nil), "Null values should be equal")
+	assert.False(t, equalValues("INTEGER", nil, int64(0)), "A null value should not be equal to zero")
+	assert.False(t, equalValues("INTEGER", int64(0), nil), "Zero should not be equal to a null value")
+
+	assert.True(t, equalValues("INTEGER", int64(7), int64(7)), "Equal integers should be equal")
+	assert.False(t, equalValues("INTEGER", int64(7), int64(8)), "Different integers should not be equal")
+	assert.True(t, equalValues("VARCHAR", "Jose", "Jose"), "Equal strings should be equal")
+	assert.True(t, equalValues("VARCHAR", "Jose", []byte("Jose")), "A string should be equal to its bytes")
+	assert.False(t, equalValues("VARCHAR", "Jose", "Maria"), "Different strings should not be equal")
+	assert.True(t, equalValues("BOOLEAN", true, true), "Equal booleans should be equal")
+	assert.True(t, equalValues("FLOAT", 1.5, 1.5), "Equal floats should be equal")
+	assert.False(t, equalValues("FLOAT", 1.0, int64(1)), "Values of different types should not be equal")
+
+	assert.True(t, equalValues("BLOB", []byte{1, 2}, []byte{1, 2}), "Equal blobs should be equal")
+	assert.False(t, equalValues("BLOB", []byte{1, 2}, []byte{2, 1}), "Different blobs should not be equal")
+
+	assert.True(t, equalValues("TIMESTAMP", now.Truncate(time.Microsecond), now), "Timestamps should be compared with the resolution of immudb")
+	assert.False(t, equalValues("TIMESTAMP", now.Truncate(time.Microsecond), now.Add(time.Second)), "Different timestamps should not be equal")
+
+	assert.True(t, equalValues("UUID", id, id.String()), "A UUID should be equal to its string")
+	assert.True(t, equalValues("UUID", id, id[:]), "A UUID should be equal to its bytes")
+	assert.False(t, equalValues("UUID", id, uuid.New().String()), "Different UUIDs should not be equal")
+}
+
+func TestEqualValuesJSON(t *testing.T) {
+
+	// Test cases
+	assert.True(t, equalValues("JSON", map[string]interface{}{"a": 1.0}, `{"a": 1}`), "JSON objects should be compared after decoding")
+	assert.True(t, equalValues("JSON", []interface{}{"x", 2.0}, []byte(`["x",2]`)), "JSON arrays should be compared after decoding")
+	assert.False(t, equalValues("JSON", map[string]interface{}{"a": 1.0}, `{"a": 2}`), "Different JSON values should not be equal")
+	assert.False(t, equalValues("JSON", map[string]interface{}{"a": 1.0}, `{"a":`), "Invalid JSON should not be equal")
+
+	// Scalar JSON values are decoded to the same types as other columns.
+	assert.True(t, equalValues("JSON", 3.0, []byte(`3`)), "A JSON number should be compared after decoding")
+	assert.True(t, equalValues("JSON", "text", `"text"`), "A JSON string should be compared after decoding")
+	assert.False(t, equalValues("JSON", "text", `text`), "A string, which is not encoded as JSON, should not be equal")
+	assert.True(t, equalValues("JSON", true, `true`), "A JSON boolean should be compared after decoding")
+	assert.True(t, equalValues("JSON", nil, `null`), "A JSON null should be compared after decoding")
+	assert.True(t, equalValues("JSON", nil, nil), "Null values should be equal")
+}